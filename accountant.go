@@ -3,11 +3,15 @@ package aicost
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"slices"
+	"time"
 
 	"github.com/awee-ai/go-tokenizer"
 )
 
 var ErrPricingModelNotFound = fmt.Errorf("model not supported")
+var ErrPricingNotEffective = fmt.Errorf("model has no pricing effective at the requested time")
 var ErrTokenizerNotFound = fmt.Errorf("tokenizer not found")
 
 // Model represents a model with its cost
@@ -15,10 +19,258 @@ type Model struct {
 	Provider string `json:"provider" yaml:"provider"`
 	Model    string `json:"model" yaml:"model"`
 	Version  string `json:"version" yaml:"version"`
-	// CostInput is the cost (usually) per 1k tokens for a query message
+	// Aliases are other names of the model, e.g. claude-3-7-sonnet-latest
+	Aliases []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	// PerTokens is the number of tokens every price of the model and its tiers is for,
+	// e.g. 1000000 for USD 0.0375 per 1M tokens, 0 and 1 both mean per token
+	PerTokens int64 `json:"per_tokens,omitempty" yaml:"per_tokens,omitempty"`
+	// CostInput is the cost per PerTokens tokens for a query message
 	CostInput Money `json:"cost_input" yaml:"cost_input"`
-	// CostOutput is the cost (usually) per tokens for an output message
+	// CostOutput is the cost per PerTokens tokens for an output message
 	CostOutput Money `json:"cost_output" yaml:"cost_output"`
+	// CostCachedInput is the cost per PerTokens tokens for input read from the provider's prompt cache
+	// when nil, cached input is charged at CostInput
+	CostCachedInput *Money `json:"cost_cached_input,omitempty" yaml:"cost_cached_input,omitempty"`
+	// CostCacheWrite is the cost per PerTokens tokens for input written to the provider's prompt cache
+	// when nil, cache writes are charged at CostInput
+	CostCacheWrite *Money `json:"cost_cache_write,omitempty" yaml:"cost_cache_write,omitempty"`
+	// CostReasoning is the cost per PerTokens reasoning tokens, when nil CostOutput is used
+	CostReasoning *Money `json:"cost_reasoning,omitempty" yaml:"cost_reasoning,omitempty"`
+	// CostImageInput is the cost per PerTokens image input tokens, when nil CostInput is used
+	CostImageInput *Money `json:"cost_image_input,omitempty" yaml:"cost_image_input,omitempty"`
+	// CostAudioInput is the cost per PerTokens audio input tokens, when nil CostInput is used
+	CostAudioInput *Money `json:"cost_audio_input,omitempty" yaml:"cost_audio_input,omitempty"`
+	// CostAudioOutput is the cost per PerTokens audio output tokens, when nil CostOutput is used
+	CostAudioOutput *Money `json:"cost_audio_output,omitempty" yaml:"cost_audio_output,omitempty"`
+	// Tiers replace the prices above once the prompt grows past their threshold
+	Tiers []PriceTier `json:"tiers,omitempty" yaml:"tiers,omitempty"`
+	// EffectiveFrom is the first moment the prices apply, nil when they always applied
+	EffectiveFrom *time.Time `json:"effective_from,omitempty" yaml:"effective_from,omitempty"`
+	// EffectiveTo is the moment the prices stopped applying, exclusive, nil when they still apply
+	EffectiveTo *time.Time `json:"effective_to,omitempty" yaml:"effective_to,omitempty"`
+}
+
+// clone returns a copy of m that shares no memory with it, the optional prices, dates, aliases and tiers are copied too
+func (m Model) clone() Model {
+	m.Aliases = slices.Clone(m.Aliases)
+	m.CostCachedInput = cloneMoney(m.CostCachedInput)
+	m.CostCacheWrite = cloneMoney(m.CostCacheWrite)
+	m.CostReasoning = cloneMoney(m.CostReasoning)
+	m.CostImageInput = cloneMoney(m.CostImageInput)
+	m.CostAudioInput = cloneMoney(m.CostAudioInput)
+	m.CostAudioOutput = cloneMoney(m.CostAudioOutput)
+	m.EffectiveFrom = cloneTime(m.EffectiveFrom)
+	m.EffectiveTo = cloneTime(m.EffectiveTo)
+
+	if m.Tiers != nil {
+		tiers := make([]PriceTier, len(m.Tiers))
+		for i, t := range m.Tiers {
+			t.CostCachedInput = cloneMoney(t.CostCachedInput)
+			t.CostCacheWrite = cloneMoney(t.CostCacheWrite)
+			t.CostImageInput = cloneMoney(t.CostImageInput)
+			t.CostAudioInput = cloneMoney(t.CostAudioInput)
+			t.CostAudioOutput = cloneMoney(t.CostAudioOutput)
+			tiers[i] = t
+		}
+		m.Tiers = tiers
+	}
+
+	return m
+}
+
+// cloneModels returns a deep copy of models, see Model.clone
+func cloneModels(models []Model) []Model {
+	cloned := make([]Model, len(models))
+	for i, m := range models {
+		cloned[i] = m.clone()
+	}
+
+	return cloned
+}
+
+func cloneMoney(m *Money) *Money {
+	if m == nil {
+		return nil
+	}
+	c := *m
+
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+
+	return &c
+}
+
+// EffectiveAt reports whether the prices of the model apply at t
+func (m Model) EffectiveAt(t time.Time) bool {
+	if m.EffectiveFrom != nil && t.Before(*m.EffectiveFrom) {
+		return false
+	}
+	if m.EffectiveTo != nil && !t.Before(*m.EffectiveTo) {
+		return false
+	}
+
+	return true
+}
+
+// PriceTier holds the prices of a model for prompts larger than AbovePromptTokens
+// like the model prices, they are for PerTokens tokens of the model
+type PriceTier struct {
+	// Name identifies the tier in cost results, e.g. "long-context"
+	Name string `json:"name" yaml:"name"`
+	// AbovePromptTokens is the prompt size after which the tier applies
+	AbovePromptTokens int64 `json:"above_prompt_tokens" yaml:"above_prompt_tokens"`
+	// CostInput is the cost per PerTokens tokens for input
+	CostInput Money `json:"cost_input" yaml:"cost_input"`
+	// CostOutput is the cost per PerTokens tokens for output
+	CostOutput Money `json:"cost_output" yaml:"cost_output"`
+	// CostCachedInput is the cost per PerTokens tokens for cached input, when nil the tier CostInput is used
+	CostCachedInput *Money `json:"cost_cached_input,omitempty" yaml:"cost_cached_input,omitempty"`
+	// CostCacheWrite is the cost per PerTokens tokens for cache writes, when nil the tier CostInput is used
+	CostCacheWrite *Money `json:"cost_cache_write,omitempty" yaml:"cost_cache_write,omitempty"`
+	// CostImageInput is the cost per PerTokens image input tokens, when nil the image price of the model is kept
+	CostImageInput *Money `json:"cost_image_input,omitempty" yaml:"cost_image_input,omitempty"`
+	// CostAudioInput is the cost per PerTokens audio input tokens, when nil the audio input price of the model is kept
+	CostAudioInput *Money `json:"cost_audio_input,omitempty" yaml:"cost_audio_input,omitempty"`
+	// CostAudioOutput is the cost per PerTokens audio output tokens, when nil the audio output price of the model is kept
+	CostAudioOutput *Money `json:"cost_audio_output,omitempty" yaml:"cost_audio_output,omitempty"`
+}
+
+// ForPrompt returns the model with the prices that apply to a prompt of promptTokens
+// and the name of the applied tier, empty when the base prices apply
+// the image and audio prices of the model are kept unless the tier sets them,
+// a tier has no reasoning price, reasoning is charged at the tier output price
+func (m Model) ForPrompt(promptTokens int64) (Model, string) {
+	var tier *PriceTier
+	for i := range m.Tiers {
+		t := &m.Tiers[i]
+		if promptTokens <= t.AbovePromptTokens {
+			continue
+		}
+		if tier == nil || t.AbovePromptTokens > tier.AbovePromptTokens {
+			tier = t
+		}
+	}
+	if tier == nil {
+		return m, ""
+	}
+
+	m.CostInput = tier.CostInput
+	m.CostOutput = tier.CostOutput
+	m.CostCachedInput = tier.CostCachedInput
+	m.CostCacheWrite = tier.CostCacheWrite
+	m.CostReasoning = nil
+	if tier.CostImageInput != nil {
+		m.CostImageInput = tier.CostImageInput
+	}
+	if tier.CostAudioInput != nil {
+		m.CostAudioInput = tier.CostAudioInput
+	}
+	if tier.CostAudioOutput != nil {
+		m.CostAudioOutput = tier.CostAudioOutput
+	}
+
+	return m, tier.Name
+}
+
+// Price returns the cost per PerTokens tokens of a usage kind, applying the fallbacks of the optional prices
+func (m Model) Price(kind UsageKind) Money {
+	var price *Money
+	fallback := m.CostInput
+
+	switch kind {
+	case UsageCachedInput:
+		price = m.CostCachedInput
+	case UsageCacheWrite:
+		price = m.CostCacheWrite
+	case UsageImage:
+		price = m.CostImageInput
+	case UsageAudioInput:
+		price = m.CostAudioInput
+	case UsageOutput:
+		fallback = m.CostOutput
+	case UsageReasoning:
+		price = m.CostReasoning
+		fallback = m.CostOutput
+	case UsageAudioOutput:
+		price = m.CostAudioOutput
+		fallback = m.CostOutput
+	}
+
+	if price != nil {
+		return *price
+	}
+
+	return fallback
+}
+
+// TokenPrice returns the price of a usage kind with the number of tokens it is for, see Price
+func (m Model) TokenPrice(kind UsageKind) TokenPrice {
+	return TokenPrice{Amount: m.Price(kind), PerTokens: m.PerTokens}
+}
+
+// TokenPrice is the price of a number of tokens, e.g. USD 0.0375 per 1M tokens
+// it holds prices that are not a whole number of nanos per token, 37.5 nanos in that example
+type TokenPrice struct {
+	Amount Money
+	// PerTokens is the number of tokens Amount is for, 0 and 1 both mean per token
+	PerTokens int64
+}
+
+// Cost returns the cost of tokens, the product is exact and rounded once to nanos, half away from zero
+func (p TokenPrice) Cost(tokens int64) (*Money, error) {
+	if p.PerTokens < 0 {
+		return nil, fmt.Errorf("price per %d tokens: per tokens must not be negative", p.PerTokens)
+	}
+
+	amount, err := p.Amount.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(big.Rat).SetInt(amount.Mul(amount, big.NewInt(tokens)))
+	if p.PerTokens > 1 {
+		total.Quo(total, new(big.Rat).SetInt64(p.PerTokens))
+	}
+
+	return moneyFromNanos(p.Amount.CurrencyCode, roundHalfUp(total))
+}
+
+// CacheUsage is the token usage of a single request that used prompt caching
+type CacheUsage struct {
+	// InputTokens are the fresh input tokens, not read from or written to the cache
+	InputTokens int64 `json:"input_tokens" yaml:"input_tokens"`
+	// CachedInputTokens are the input tokens read from the cache
+	CachedInputTokens int64 `json:"cached_input_tokens" yaml:"cached_input_tokens"`
+	// CacheWriteTokens are the input tokens written to the cache
+	CacheWriteTokens int64 `json:"cache_write_tokens" yaml:"cache_write_tokens"`
+	// OutputTokens are the generated tokens
+	OutputTokens int64 `json:"output_tokens" yaml:"output_tokens"`
+}
+
+// CostLine is the cost of a number of tokens in the model currency and in the user currency
+type CostLine struct {
+	Tokens    int64  `json:"tokens" yaml:"tokens"`
+	Cost      *Money `json:"cost" yaml:"cost"`
+	Converted *Money `json:"converted" yaml:"converted"`
+}
+
+// CacheCost is the itemized cost of a CacheUsage
+type CacheCost struct {
+	// Tier is the name of the applied price tier, empty for the base prices
+	Tier string `json:"tier,omitempty" yaml:"tier,omitempty"`
+	// Resolution records how the requested name was resolved, nil when it matched the model exactly
+	Resolution  *Resolution `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	Input       CostLine    `json:"input" yaml:"input"`
+	CachedInput CostLine    `json:"cached_input" yaml:"cached_input"`
+	CacheWrite  CostLine    `json:"cache_write" yaml:"cache_write"`
+	Output      CostLine    `json:"output" yaml:"output"`
+	Total       CostLine    `json:"total" yaml:"total"`
 }
 
 // Accountant is an interface for model cost calculation
@@ -26,34 +278,39 @@ type Accountant interface {
 	TokenCount(provider, model string, content string) (int64, error)
 	CostForModelInput(provider, model string, userCurrency string, tokens int64) (*Money, *Money, error)
 	CostForModelOutput(provider, model string, userCurrency string, tokens int64) (*Money, *Money, error)
+	CostForUsage(provider, model string, userCurrency string, usage Usage) (*CostBreakdown, error)
 	Models(models []Model) []Model
 }
 
-// Counter is a pricing calculator
+// Counter is a pricing calculator, safe for concurrent use
 type Counter struct {
-	models    []Model
+	models    *registry
 	converter Converter
 }
 
 var _ Accountant = (*Counter)(nil)
 
 // NewAccountant returns a new pricing
+// when models is nil the built-in catalog is used, see DefaultModels
 func NewAccountant(models []Model, converter Converter, bpe bool) *Counter {
+	if models == nil {
+		models = DefaultModels()
+	}
+
 	return &Counter{
-		models:    models,
+		models:    newRegistry(models),
 		converter: converter,
 	}
 }
 
 // Models returns or sets the models
+// setting swaps the models atomically, cost calls in flight finish with the previous models
 func (p *Counter) Models(models []Model) []Model {
 	if models != nil {
-		p.models = models
-
-		return p.models
+		p.models.store(models)
 	}
 
-	return p.models
+	return p.models.load().all()
 }
 
 // TokenCount returns the token count for a message
@@ -74,13 +331,25 @@ func (p *Counter) TokenCount(provider, model string, content string) (int64, err
 }
 
 // CostForModelInput returns the cost for a model query
+// the price tier is picked by treating tokens as the prompt size
 func (p *Counter) CostForModelInput(provider, model string, userCurrency string, tokens int64) (*Money, *Money, error) {
-	pricingModel := p.findModel(provider, model)
-	if pricingModel == nil {
-		return nil, nil, fmt.Errorf("failed to find model for input cost %s: %w", model, ErrPricingModelNotFound)
+	return p.costForModelInput(provider, model, userCurrency, tokens, time.Now(), p.converter.Convert)
+}
+
+// CostForModelInputAt returns the cost for a model query at the prices effective at the given time,
+// converted with the rates in effect at that time when the converter is a DatedConverter
+func (p *Counter) CostForModelInputAt(provider, model string, userCurrency string, tokens int64, at time.Time) (*Money, *Money, error) {
+	return p.costForModelInput(provider, model, userCurrency, tokens, at, p.convertAt(at))
+}
+
+func (p *Counter) costForModelInput(provider, model string, userCurrency string, tokens int64, at time.Time, convert convertFunc) (*Money, *Money, error) {
+	pricingModel, err := p.findModelAt(provider, model, at)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find model for input cost %s: %w", model, err)
 	}
 
-	cost, convertedCost, err := p.calculateCost(tokens, pricingModel.CostInput, userCurrency)
+	tiered, _ := pricingModel.ForPrompt(tokens)
+	cost, convertedCost, err := p.calculatePriceCost(tokens, tiered.TokenPrice(UsageInput), userCurrency, convert)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -89,13 +358,25 @@ func (p *Counter) CostForModelInput(provider, model string, userCurrency string,
 }
 
 // CostForModelOutput returns the cost for a model output
+// the prompt size is unknown here, so the base prices are used,
+// use CostForUsage for models with price tiers
 func (p *Counter) CostForModelOutput(provider, model string, userCurrency string, tokens int64) (*Money, *Money, error) {
-	pricingModel := p.findModel(provider, model)
-	if pricingModel == nil {
-		return nil, nil, fmt.Errorf("failed to find model for output cost %s: %w", model, ErrPricingModelNotFound)
+	return p.costForModelOutput(provider, model, userCurrency, tokens, time.Now(), p.converter.Convert)
+}
+
+// CostForModelOutputAt returns the cost for a model output at the prices effective at the given time,
+// converted with the rates in effect at that time when the converter is a DatedConverter
+func (p *Counter) CostForModelOutputAt(provider, model string, userCurrency string, tokens int64, at time.Time) (*Money, *Money, error) {
+	return p.costForModelOutput(provider, model, userCurrency, tokens, at, p.convertAt(at))
+}
+
+func (p *Counter) costForModelOutput(provider, model string, userCurrency string, tokens int64, at time.Time, convert convertFunc) (*Money, *Money, error) {
+	pricingModel, err := p.findModelAt(provider, model, at)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find model for output cost %s: %w", model, err)
 	}
 
-	cost, convertedCost, err := p.calculateCost(tokens, pricingModel.CostOutput, userCurrency)
+	cost, convertedCost, err := p.calculatePriceCost(tokens, pricingModel.TokenPrice(UsageOutput), userCurrency, convert)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -103,29 +384,179 @@ func (p *Counter) CostForModelOutput(provider, model string, userCurrency string
 	return cost, convertedCost, nil
 }
 
-func (p *Counter) findModel(provider, model string) *Model {
-	var mod *Model
-	for _, m := range p.models {
-		if m.Provider != provider {
+// CostForCacheUsage returns the cost of each line of a prompt caching usage and their total, see CostForUsage
+// the price tier is picked by the full prompt size, fresh, cached and cache write tokens together
+func (p *Counter) CostForCacheUsage(provider, model string, userCurrency string, usage CacheUsage) (*CacheCost, error) {
+	return p.costForCacheUsage(provider, model, userCurrency, usage, time.Now(), p.converter.Convert)
+}
+
+// CostForCacheUsageAt returns the cost of a prompt caching usage at the prices effective at the given time,
+// converted with the rates in effect at that time when the converter is a DatedConverter
+func (p *Counter) CostForCacheUsageAt(provider, model string, userCurrency string, usage CacheUsage, at time.Time) (*CacheCost, error) {
+	return p.costForCacheUsage(provider, model, userCurrency, usage, at, p.convertAt(at))
+}
+
+func (p *Counter) costForCacheUsage(provider, model string, userCurrency string, usage CacheUsage, at time.Time, convert convertFunc) (*CacheCost, error) {
+	breakdown, err := p.costForUsage(provider, model, userCurrency, Usage{
+		InputTokens:       usage.InputTokens,
+		CachedInputTokens: usage.CachedInputTokens,
+		CacheWriteTokens:  usage.CacheWriteTokens,
+		OutputTokens:      usage.OutputTokens,
+	}, at, convert)
+	if err != nil {
+		return nil, err
+	}
+
+	// the kinds without tokens have no line in the breakdown, they cost zero in both currencies
+	line := func(kind UsageKind) CostLine {
+		if l := breakdown.Line(kind); l != nil {
+			return l.CostLine
+		}
+		return CostLine{
+			Cost:      &Money{CurrencyCode: breakdown.Total.Cost.CurrencyCode},
+			Converted: &Money{CurrencyCode: breakdown.Total.Converted.CurrencyCode},
+		}
+	}
+
+	return &CacheCost{
+		Tier:        breakdown.Tier,
+		Resolution:  breakdown.Resolution,
+		Input:       line(UsageInput),
+		CachedInput: line(UsageCachedInput),
+		CacheWrite:  line(UsageCacheWrite),
+		Output:      line(UsageOutput),
+		Total:       breakdown.Total,
+	}, nil
+}
+
+// CostForUsage returns the itemized cost of a usage, a line for every kind of tokens used and their total
+// the price tier is picked by the prompt size, see Usage.PromptTokens, a negative token count is an error
+func (p *Counter) CostForUsage(provider, model string, userCurrency string, usage Usage) (*CostBreakdown, error) {
+	return p.costForUsage(provider, model, userCurrency, usage, time.Now(), p.converter.Convert)
+}
+
+// CostForUsageAt returns the itemized cost of a usage at the prices effective at the given time,
+// converted with the rates in effect at that time when the converter is a DatedConverter
+func (p *Counter) CostForUsageAt(provider, model string, userCurrency string, usage Usage, at time.Time) (*CostBreakdown, error) {
+	return p.costForUsage(provider, model, userCurrency, usage, at, p.convertAt(at))
+}
+
+func (p *Counter) costForUsage(provider, model string, userCurrency string, usage Usage, at time.Time, convert convertFunc) (*CostBreakdown, error) {
+	if err := usage.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid usage for %s: %w", model, err)
+	}
+
+	found, resolution, err := p.ResolveModel(provider, model, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find model for usage cost %s: %w", model, err)
+	}
+
+	pricingModel, tier := found.ForPrompt(usage.PromptTokens())
+
+	// the zero line validates the conversion and keeps the total in the right currencies when nothing was used
+	zeroCost, zeroConverted, err := p.calculatePriceCost(0, pricingModel.TokenPrice(UsageInput), userCurrency, convert)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CostBreakdown{
+		Provider: found.Provider,
+		Model:    found.Model,
+		Tier:     tier,
+	}
+	if len(resolution.Steps) > 0 {
+		result.Resolution = resolution
+	}
+	costLines := []CostLine{{Cost: zeroCost, Converted: zeroConverted}}
+	for _, kind := range usageKinds {
+		tokens := usage.Tokens(kind)
+		if tokens == 0 {
 			continue
 		}
-		if m.Model == model {
-			mod = &m
-			break
+
+		cost, convertedCost, err := p.calculatePriceCost(tokens, pricingModel.TokenPrice(kind), userCurrency, convert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate %s cost: %w", kind, err)
 		}
+		line := CostLine{Tokens: tokens, Cost: cost, Converted: convertedCost}
+		result.Lines = append(result.Lines, BreakdownLine{Kind: kind, CostLine: line})
+		costLines = append(costLines, line)
 	}
+
+	total, err := sumCostLines(costLines...)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = *total
+
+	return result, nil
+}
+
+// sumCostLines adds up the tokens and both costs of the given lines
+func sumCostLines(lines ...CostLine) (*CostLine, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no cost lines to sum")
+	}
+
+	total := CostLine{Cost: lines[0].Cost, Converted: lines[0].Converted, Tokens: lines[0].Tokens}
+	for _, l := range lines[1:] {
+		cost, err := total.Cost.Add(l.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add cost: %w", err)
+		}
+		converted, err := total.Converted.Add(l.Converted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add converted cost: %w", err)
+		}
+		total = CostLine{Tokens: total.Tokens + l.Tokens, Cost: cost, Converted: converted}
+	}
+
+	return &total, nil
+}
+
+// findModel returns the model with the prices effective now, nil when there is none
+func (p *Counter) findModel(provider, model string) *Model {
+	mod, _ := p.findModelAt(provider, model, time.Now())
 	return mod
 }
 
+// findModelAt resolves the model name and returns the model with the prices effective at the given time
+// see ResolveModel
+func (p *Counter) findModelAt(provider, model string, at time.Time) (*Model, error) {
+	mod, _, err := p.ResolveModel(provider, model, at)
+	return mod, err
+}
+
+// convertFunc converts an amount to another currency, see Converter.Convert
+type convertFunc func(amount Money, toCurrency string) (*Money, error)
+
+// convertAt returns the conversion with the rates in effect at the given time when the converter is a DatedConverter,
+// otherwise the conversion with its current rates
+func (p *Counter) convertAt(at time.Time) convertFunc {
+	if dated, ok := p.converter.(DatedConverter); ok {
+		return func(amount Money, toCurrency string) (*Money, error) {
+			return dated.ConvertAt(amount, toCurrency, at)
+		}
+	}
+
+	return p.converter.Convert
+}
+
 func (p *Counter) calculateCost(tokens int64, costPerToken Money, userCurrency string) (*Money, *Money, error) {
-	cost, err := costPerToken.Times(tokens)
+	return p.calculatePriceCost(tokens, TokenPrice{Amount: costPerToken}, userCurrency, p.converter.Convert)
+}
+
+// calculatePriceCost returns the cost of tokens at price and its conversion to userCurrency with convert,
+// the cost is rounded to nanos once, before the conversion
+func (p *Counter) calculatePriceCost(tokens int64, price TokenPrice, userCurrency string, convert convertFunc) (*Money, *Money, error) {
+	cost, err := price.Cost(tokens)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to multiply tokens %d: %w", tokens, err)
 	}
 
-	converted, err := p.converter.Convert(*cost, userCurrency)
+	converted, err := convert(*cost, userCurrency)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to convert cost from %s to %s: %w", costPerToken.CurrencyCode, userCurrency, err)
+		return nil, nil, fmt.Errorf("failed to convert cost from %s to %s: %w", price.Amount.CurrencyCode, userCurrency, err)
 	}
 
 	return cost, converted, nil
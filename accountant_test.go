@@ -1,7 +1,9 @@
 package aicost
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -605,3 +607,816 @@ func Test_Counter_calculateCost(t *testing.T) {
 		})
 	}
 }
+
+func Test_Counter_CostForCacheUsage(t *testing.T) {
+	// Setup test models
+	testModels := []Model{
+		{
+			Provider: "anthropic",
+			Model:    "claude-3",
+			Version:  "1",
+			CostInput: Money{
+				Units:        0,
+				Nanos:        3000, // $3 per 1M tokens
+				CurrencyCode: "USD",
+			},
+			CostOutput: Money{
+				Units:        0,
+				Nanos:        15000,
+				CurrencyCode: "USD",
+			},
+			CostCachedInput: &Money{
+				Units:        0,
+				Nanos:        300,
+				CurrencyCode: "USD",
+			},
+			CostCacheWrite: &Money{
+				Units:        0,
+				Nanos:        3750,
+				CurrencyCode: "USD",
+			},
+		},
+		{
+			Provider: "openai",
+			Model:    "gpt-4",
+			Version:  "1",
+			CostInput: Money{
+				Units:        0,
+				Nanos:        3000,
+				CurrencyCode: "USD",
+			},
+			CostOutput: Money{
+				Units:        0,
+				Nanos:        6000,
+				CurrencyCode: "USD",
+			},
+		},
+	}
+
+	con := NewConverter("USD", testRates)
+	accountant := NewAccountant(testModels, con, false)
+
+	tests := []struct {
+		name         string
+		provider     string
+		model        string
+		userCurrency string
+		usage        CacheUsage
+		want         *CacheCost
+		wantErr      bool
+	}{
+		{
+			name:         "cached prices in USD",
+			provider:     "anthropic",
+			model:        "claude-3",
+			userCurrency: "USD",
+			usage: CacheUsage{
+				InputTokens:       1000,
+				CachedInputTokens: 100000,
+				CacheWriteTokens:  2000,
+				OutputTokens:      500,
+			},
+			want: &CacheCost{
+				Input: CostLine{
+					Tokens:    1000,
+					Cost:      &Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+				},
+				CachedInput: CostLine{
+					Tokens:    100000,
+					Cost:      &Money{Units: 0, Nanos: 30000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 30000000, CurrencyCode: "USD"},
+				},
+				CacheWrite: CostLine{
+					Tokens:    2000,
+					Cost:      &Money{Units: 0, Nanos: 7500000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 7500000, CurrencyCode: "USD"},
+				},
+				Output: CostLine{
+					Tokens:    500,
+					Cost:      &Money{Units: 0, Nanos: 7500000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 7500000, CurrencyCode: "USD"},
+				},
+				Total: CostLine{
+					Tokens:    103500,
+					Cost:      &Money{Units: 0, Nanos: 48000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 48000000, CurrencyCode: "USD"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:         "cache prices fall back to input price",
+			provider:     "openai",
+			model:        "gpt-4",
+			userCurrency: "EUR",
+			usage: CacheUsage{
+				InputTokens:       1000,
+				CachedInputTokens: 1000,
+				CacheWriteTokens:  0,
+				OutputTokens:      1000,
+			},
+			want: &CacheCost{
+				Input: CostLine{
+					Tokens:    1000,
+					Cost:      &Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 2550000, CurrencyCode: "EUR"},
+				},
+				CachedInput: CostLine{
+					Tokens:    1000,
+					Cost:      &Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 2550000, CurrencyCode: "EUR"},
+				},
+				CacheWrite: CostLine{
+					Tokens:    0,
+					Cost:      &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 0, CurrencyCode: "EUR"},
+				},
+				Output: CostLine{
+					Tokens:    1000,
+					Cost:      &Money{Units: 0, Nanos: 6000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 5100000, CurrencyCode: "EUR"},
+				},
+				Total: CostLine{
+					Tokens:    3000,
+					Cost:      &Money{Units: 0, Nanos: 12000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 10200000, CurrencyCode: "EUR"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:         "model not found",
+			provider:     "openai",
+			model:        "nonexistent-model",
+			userCurrency: "USD",
+			usage:        CacheUsage{InputTokens: 1000},
+			want:         nil,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := accountant.CostForCacheUsage(tt.provider, tt.model, tt.userCurrency, tt.usage)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Model_ForPrompt(t *testing.T) {
+	model := Model{
+		Provider:   "google",
+		Model:      "gemini-pro",
+		CostInput:  Money{Units: 0, Nanos: 1250, CurrencyCode: "USD"},
+		CostOutput: Money{Units: 0, Nanos: 10000, CurrencyCode: "USD"},
+		Tiers: []PriceTier{
+			{
+				Name:              "long-context",
+				AbovePromptTokens: 200000,
+				CostInput:         Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"},
+				CostOutput:        Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+			},
+			{
+				Name:              "huge-context",
+				AbovePromptTokens: 1000000,
+				CostInput:         Money{Units: 0, Nanos: 5000, CurrencyCode: "USD"},
+				CostOutput:        Money{Units: 0, Nanos: 30000, CurrencyCode: "USD"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		promptTokens int64
+		wantTier     string
+		wantInput    Money
+		wantOutput   Money
+	}{
+		{
+			name:         "base prices below the threshold",
+			promptTokens: 1000,
+			wantTier:     "",
+			wantInput:    Money{Units: 0, Nanos: 1250, CurrencyCode: "USD"},
+			wantOutput:   Money{Units: 0, Nanos: 10000, CurrencyCode: "USD"},
+		},
+		{
+			name:         "base prices at the threshold",
+			promptTokens: 200000,
+			wantTier:     "",
+			wantInput:    Money{Units: 0, Nanos: 1250, CurrencyCode: "USD"},
+			wantOutput:   Money{Units: 0, Nanos: 10000, CurrencyCode: "USD"},
+		},
+		{
+			name:         "first tier above the threshold",
+			promptTokens: 200001,
+			wantTier:     "long-context",
+			wantInput:    Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"},
+			wantOutput:   Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+		},
+		{
+			name:         "highest matching tier",
+			promptTokens: 2000000,
+			wantTier:     "huge-context",
+			wantInput:    Money{Units: 0, Nanos: 5000, CurrencyCode: "USD"},
+			wantOutput:   Money{Units: 0, Nanos: 30000, CurrencyCode: "USD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, tier := model.ForPrompt(tt.promptTokens)
+			assert.Equal(t, tt.wantTier, tier)
+			assert.Equal(t, tt.wantInput, got.CostInput)
+			assert.Equal(t, tt.wantOutput, got.CostOutput)
+		})
+	}
+}
+
+func Test_Model_ForPrompt_SpecialPrices(t *testing.T) {
+	audio := Money{Units: 0, Nanos: 1000, CurrencyCode: "USD"}
+	image := Money{Units: 0, Nanos: 400, CurrencyCode: "USD"}
+	tierAudio := Money{Units: 0, Nanos: 2000, CurrencyCode: "USD"}
+	reasoning := Money{Units: 0, Nanos: 12000, CurrencyCode: "USD"}
+
+	model := Model{
+		Provider:       "google",
+		Model:          "gemini-flash",
+		CostInput:      Money{Units: 0, Nanos: 300, CurrencyCode: "USD"},
+		CostOutput:     Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"},
+		CostReasoning:  &reasoning,
+		CostImageInput: &image,
+		CostAudioInput: &audio,
+		Tiers: []PriceTier{
+			{
+				Name:              "long-context",
+				AbovePromptTokens: 200000,
+				CostInput:         Money{Units: 0, Nanos: 600, CurrencyCode: "USD"},
+				CostOutput:        Money{Units: 0, Nanos: 5000, CurrencyCode: "USD"},
+			},
+			{
+				Name:              "huge-context",
+				AbovePromptTokens: 1000000,
+				CostInput:         Money{Units: 0, Nanos: 900, CurrencyCode: "USD"},
+				CostOutput:        Money{Units: 0, Nanos: 7500, CurrencyCode: "USD"},
+				CostAudioInput:    &tierAudio,
+			},
+		},
+	}
+
+	// the audio and image prices of the model are kept, reasoning follows the tier output
+	got, tier := model.ForPrompt(300000)
+	assert.Equal(t, "long-context", tier)
+	assert.Equal(t, audio, got.Price(UsageAudioInput))
+	assert.Equal(t, image, got.Price(UsageImage))
+	assert.Equal(t, Money{Units: 0, Nanos: 5000, CurrencyCode: "USD"}, got.Price(UsageReasoning))
+	assert.Equal(t, Money{Units: 0, Nanos: 5000, CurrencyCode: "USD"}, got.Price(UsageAudioOutput))
+
+	// unless the tier sets them
+	got, tier = model.ForPrompt(2000000)
+	assert.Equal(t, "huge-context", tier)
+	assert.Equal(t, tierAudio, got.Price(UsageAudioInput))
+	assert.Equal(t, image, got.Price(UsageImage))
+}
+
+func Test_Counter_TieredCost(t *testing.T) {
+	testModels := []Model{
+		{
+			Provider:   "google",
+			Model:      "gemini-pro",
+			CostInput:  Money{Units: 0, Nanos: 1250, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 10000, CurrencyCode: "USD"},
+			Tiers: []PriceTier{
+				{
+					Name:              "long-context",
+					AbovePromptTokens: 200000,
+					CostInput:         Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"},
+					CostOutput:        Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+				},
+			},
+		},
+	}
+
+	con := NewConverter("USD", testRates)
+	accountant := NewAccountant(testModels, con, false)
+
+	cost, _, err := accountant.CostForModelInput("google", "gemini-pro", "USD", 300000)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 750000000, CurrencyCode: "USD"}, cost)
+
+	usage, err := accountant.CostForCacheUsage("google", "gemini-pro", "USD", CacheUsage{
+		InputTokens:       100000,
+		CachedInputTokens: 150000,
+		OutputTokens:      1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "long-context", usage.Tier)
+	assert.Equal(t, &Money{Units: 0, Nanos: 15000000, CurrencyCode: "USD"}, usage.Output.Cost)
+	assert.Equal(t, &Money{Units: 0, Nanos: 640000000, CurrencyCode: "USD"}, usage.Total.Cost)
+
+	// the breakdown of the same usage has the same total
+	breakdown, err := accountant.CostForUsage("google", "gemini-pro", "USD", Usage{
+		InputTokens:       100000,
+		CachedInputTokens: 150000,
+		OutputTokens:      1000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, breakdown.Total, usage.Total)
+	assert.Nil(t, usage.Resolution)
+
+	// a dated snapshot name is resolved like in CostForUsage
+	usage, err = accountant.CostForCacheUsage("google", "gemini-pro-2025-01-01", "USD", CacheUsage{InputTokens: 1000})
+	assert.NoError(t, err)
+	if assert.NotNil(t, usage.Resolution) {
+		assert.Equal(t, "gemini-pro", usage.Resolution.Model)
+	}
+}
+
+func Test_Model_Price(t *testing.T) {
+	input := Money{Units: 0, Nanos: 1000, CurrencyCode: "USD"}
+	output := Money{Units: 0, Nanos: 2000, CurrencyCode: "USD"}
+	audio := Money{Units: 0, Nanos: 40000, CurrencyCode: "USD"}
+
+	model := Model{
+		CostInput:       input,
+		CostOutput:      output,
+		CostAudioOutput: &audio,
+	}
+
+	tests := []struct {
+		kind UsageKind
+		want Money
+	}{
+		{kind: UsageInput, want: input},
+		{kind: UsageCachedInput, want: input},
+		{kind: UsageCacheWrite, want: input},
+		{kind: UsageImage, want: input},
+		{kind: UsageAudioInput, want: input},
+		{kind: UsageOutput, want: output},
+		{kind: UsageReasoning, want: output},
+		{kind: UsageAudioOutput, want: audio},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			assert.Equal(t, tt.want, model.Price(tt.kind))
+		})
+	}
+}
+
+func Test_TokenPrice_Cost(t *testing.T) {
+	// USD 0.0375 per 1M tokens is 37.5 nanos per token and USD 0.0003 per 1M tokens is 0.3 nanos
+	halfNano := TokenPrice{Amount: Money{Units: 0, Nanos: 37500000, CurrencyCode: "USD"}, PerTokens: 1000000}
+	subNano := TokenPrice{Amount: Money{Units: 0, Nanos: 300000, CurrencyCode: "USD"}, PerTokens: 1000000}
+
+	tests := []struct {
+		name    string
+		price   TokenPrice
+		tokens  int64
+		want    *Money
+		wantErr bool
+	}{
+		{name: "one token rounds half up", price: halfNano, tokens: 1, want: &Money{Units: 0, Nanos: 38, CurrencyCode: "USD"}},
+		{name: "two tokens are exact", price: halfNano, tokens: 2, want: &Money{Units: 0, Nanos: 75, CurrencyCode: "USD"}},
+		{name: "million tokens", price: halfNano, tokens: 1000000, want: &Money{Units: 0, Nanos: 37500000, CurrencyCode: "USD"}},
+		{name: "billion tokens", price: halfNano, tokens: 1000000000, want: &Money{Units: 37, Nanos: 500000000, CurrencyCode: "USD"}},
+		{name: "sub nano one token", price: subNano, tokens: 1, want: &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"}},
+		{name: "sub nano thousand tokens", price: subNano, tokens: 1000, want: &Money{Units: 0, Nanos: 300, CurrencyCode: "USD"}},
+		{
+			name:   "per token",
+			price:  TokenPrice{Amount: Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"}},
+			tokens: 1000,
+			want:   &Money{Units: 0, Nanos: 2500000, CurrencyCode: "USD"},
+		},
+		{
+			name:   "per one token",
+			price:  TokenPrice{Amount: Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"}, PerTokens: 1},
+			tokens: 1000,
+			want:   &Money{Units: 0, Nanos: 2500000, CurrencyCode: "USD"},
+		},
+		{
+			name:    "negative per tokens",
+			price:   TokenPrice{Amount: Money{Units: 1, CurrencyCode: "USD"}, PerTokens: -1},
+			tokens:  1,
+			wantErr: true,
+		},
+		{
+			name:    "overflow",
+			price:   TokenPrice{Amount: Money{Units: math.MaxInt64, CurrencyCode: "USD"}, PerTokens: 2},
+			tokens:  4,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.price.Cost(tt.tokens)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Counter_CostPerTokens(t *testing.T) {
+	models := []Model{
+		{
+			Provider:   "google",
+			Model:      "gemini-1.5-flash-8b",
+			PerTokens:  1000000,
+			CostInput:  Money{Units: 0, Nanos: 37500000, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 150000000, CurrencyCode: "USD"},
+			Tiers: []PriceTier{
+				{
+					Name:              "long-context",
+					AbovePromptTokens: 128000,
+					CostInput:         Money{Units: 0, Nanos: 75000000, CurrencyCode: "USD"},
+					CostOutput:        Money{Units: 0, Nanos: 300000000, CurrencyCode: "USD"},
+				},
+			},
+		},
+	}
+	accountant := NewAccountant(models, NewConverter("USD", testRates), false)
+
+	// a per token Money cannot hold 37.5 nanos, the cost is rounded once for all the tokens
+	cost, converted, err := accountant.CostForModelInput("google", "gemini-1.5-flash-8b", "EUR", 1001)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 37538, CurrencyCode: "USD"}, cost)
+	assert.Equal(t, &Money{Units: 0, Nanos: 31907, CurrencyCode: "EUR"}, converted)
+
+	cost, _, err = accountant.CostForModelOutput("google", "gemini-1.5-flash-8b", "USD", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 1500, CurrencyCode: "USD"}, cost)
+
+	// the tier prices are per 1M tokens too
+	breakdown, err := accountant.CostForUsage("google", "gemini-1.5-flash-8b", "USD", Usage{InputTokens: 200000, OutputTokens: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, "long-context", breakdown.Tier)
+	assert.Equal(t, &Money{Units: 0, Nanos: 15000000, CurrencyCode: "USD"}, breakdown.Line(UsageInput).Cost)
+	assert.Equal(t, &Money{Units: 0, Nanos: 300000, CurrencyCode: "USD"}, breakdown.Line(UsageOutput).Cost)
+	assert.Equal(t, &Money{Units: 0, Nanos: 15300000, CurrencyCode: "USD"}, breakdown.Total.Cost)
+}
+
+func Test_Counter_CostForUsage(t *testing.T) {
+	// Setup test models
+	testModels := []Model{
+		{
+			Provider: "openai",
+			Model:    "o1",
+			Version:  "1",
+			CostInput: Money{
+				Units:        0,
+				Nanos:        15000, // $15 per 1M tokens
+				CurrencyCode: "USD",
+			},
+			CostOutput: Money{
+				Units:        0,
+				Nanos:        60000,
+				CurrencyCode: "USD",
+			},
+			CostCachedInput: &Money{
+				Units:        0,
+				Nanos:        7500,
+				CurrencyCode: "USD",
+			},
+			CostImageInput: &Money{
+				Units:        0,
+				Nanos:        20000,
+				CurrencyCode: "USD",
+			},
+		},
+	}
+
+	con := NewConverter("USD", testRates)
+	accountant := NewAccountant(testModels, con, false)
+
+	tests := []struct {
+		name         string
+		provider     string
+		model        string
+		userCurrency string
+		usage        Usage
+		want         *CostBreakdown
+		wantErr      bool
+	}{
+		{
+			name:         "itemized usage in EUR",
+			provider:     "openai",
+			model:        "o1",
+			userCurrency: "EUR",
+			usage: Usage{
+				InputTokens:       1000,
+				OutputTokens:      500,
+				CachedInputTokens: 2000,
+				ReasoningTokens:   1000,
+				ImageTokens:       100,
+			},
+			want: &CostBreakdown{
+				Provider: "openai",
+				Model:    "o1",
+				Lines: []BreakdownLine{
+					{Kind: UsageInput, CostLine: CostLine{
+						Tokens:    1000,
+						Cost:      &Money{Units: 0, Nanos: 15000000, CurrencyCode: "USD"},
+						Converted: &Money{Units: 0, Nanos: 12750000, CurrencyCode: "EUR"},
+					}},
+					{Kind: UsageCachedInput, CostLine: CostLine{
+						Tokens:    2000,
+						Cost:      &Money{Units: 0, Nanos: 15000000, CurrencyCode: "USD"},
+						Converted: &Money{Units: 0, Nanos: 12750000, CurrencyCode: "EUR"},
+					}},
+					{Kind: UsageImage, CostLine: CostLine{
+						Tokens:    100,
+						Cost:      &Money{Units: 0, Nanos: 2000000, CurrencyCode: "USD"},
+						Converted: &Money{Units: 0, Nanos: 1700000, CurrencyCode: "EUR"},
+					}},
+					{Kind: UsageOutput, CostLine: CostLine{
+						Tokens:    500,
+						Cost:      &Money{Units: 0, Nanos: 30000000, CurrencyCode: "USD"},
+						Converted: &Money{Units: 0, Nanos: 25500000, CurrencyCode: "EUR"},
+					}},
+					{Kind: UsageReasoning, CostLine: CostLine{
+						Tokens:    1000,
+						Cost:      &Money{Units: 0, Nanos: 60000000, CurrencyCode: "USD"},
+						Converted: &Money{Units: 0, Nanos: 51000000, CurrencyCode: "EUR"},
+					}},
+				},
+				Total: CostLine{
+					Tokens:    4600,
+					Cost:      &Money{Units: 0, Nanos: 122000000, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 103700000, CurrencyCode: "EUR"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:         "empty usage",
+			provider:     "openai",
+			model:        "o1",
+			userCurrency: "USD",
+			usage:        Usage{},
+			want: &CostBreakdown{
+				Provider: "openai",
+				Model:    "o1",
+				Total: CostLine{
+					Tokens:    0,
+					Cost:      &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+					Converted: &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:         "conversion error",
+			provider:     "openai",
+			model:        "o1",
+			userCurrency: "CAD",
+			usage:        Usage{InputTokens: 1000},
+			want:         nil,
+			wantErr:      true,
+		},
+		{
+			name:         "model not found",
+			provider:     "openai",
+			model:        "nonexistent-model",
+			userCurrency: "USD",
+			usage:        Usage{InputTokens: 1000},
+			want:         nil,
+			wantErr:      true,
+		},
+		{
+			name:         "negative input tokens",
+			provider:     "openai",
+			model:        "o1",
+			userCurrency: "USD",
+			usage:        Usage{InputTokens: -5},
+			want:         nil,
+			wantErr:      true,
+		},
+		{
+			name:         "negative reasoning tokens",
+			provider:     "openai",
+			model:        "o1",
+			userCurrency: "USD",
+			usage:        Usage{InputTokens: 1000, ReasoningTokens: -1},
+			want:         nil,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := accountant.CostForUsage(tt.provider, tt.model, tt.userCurrency, tt.usage)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Counter_CostAt(t *testing.T) {
+	priceCut := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	launch := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Setup test models
+	testModels := []Model{
+		{
+			Provider:      "openai",
+			Model:         "o3",
+			Version:       "1",
+			CostInput:     Money{Units: 0, Nanos: 10000, CurrencyCode: "USD"},
+			CostOutput:    Money{Units: 0, Nanos: 40000, CurrencyCode: "USD"},
+			EffectiveFrom: &launch,
+			EffectiveTo:   &priceCut,
+		},
+		{
+			Provider:      "openai",
+			Model:         "o3",
+			Version:       "2",
+			CostInput:     Money{Units: 0, Nanos: 2000, CurrencyCode: "USD"},
+			CostOutput:    Money{Units: 0, Nanos: 8000, CurrencyCode: "USD"},
+			EffectiveFrom: &priceCut,
+		},
+	}
+
+	con := NewConverter("USD", testRates)
+	accountant := NewAccountant(testModels, con, false)
+
+	tests := []struct {
+		name       string
+		model      string
+		at         time.Time
+		wantInput  *Money
+		wantOutput *Money
+		wantErr    error
+	}{
+		{
+			name:       "before the price cut",
+			model:      "o3",
+			at:         time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+			wantInput:  &Money{Units: 0, Nanos: 10000000, CurrencyCode: "USD"},
+			wantOutput: &Money{Units: 0, Nanos: 40000000, CurrencyCode: "USD"},
+		},
+		{
+			name:       "at the price cut",
+			model:      "o3",
+			at:         priceCut,
+			wantInput:  &Money{Units: 0, Nanos: 2000000, CurrencyCode: "USD"},
+			wantOutput: &Money{Units: 0, Nanos: 8000000, CurrencyCode: "USD"},
+		},
+		{
+			name:    "before launch",
+			model:   "o3",
+			at:      time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			wantErr: ErrPricingNotEffective,
+		},
+		{
+			name:    "unknown model",
+			model:   "o5",
+			at:      priceCut,
+			wantErr: ErrPricingModelNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _, err := accountant.CostForModelInputAt("openai", tt.model, "USD", 1000, tt.at)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, input)
+
+				_, err = accountant.CostForUsageAt("openai", tt.model, "USD", Usage{InputTokens: 1000}, tt.at)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantInput, input)
+
+			output, _, err := accountant.CostForModelOutputAt("openai", tt.model, "USD", 1000, tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOutput, output)
+
+			breakdown, err := accountant.CostForUsageAt("openai", tt.model, "USD", Usage{InputTokens: 1000, OutputTokens: 1000}, tt.at)
+			assert.NoError(t, err)
+			total, err := tt.wantInput.Add(tt.wantOutput)
+			assert.NoError(t, err)
+			assert.Equal(t, total, breakdown.Total.Cost)
+		})
+	}
+
+	// the current prices are used without a timestamp
+	input, _, err := accountant.CostForModelInput("openai", "o3", "USD", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 2000000, CurrencyCode: "USD"}, input)
+}
+
+func Test_Counter_CostAt_Rates(t *testing.T) {
+	testModels := []Model{
+		{
+			Provider:   "openai",
+			Model:      "gpt-4o",
+			CostInput:  Money{Units: 1, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 1, CurrencyCode: "USD"},
+		},
+	}
+	day := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	con := NewConverter("USD", testRates)
+	assert.NoError(t, con.RatesAt(day, map[string]float64{"EUR": 0.92}))
+	accountant := NewAccountant(testModels, con, false)
+
+	tests := []struct {
+		name     string
+		fallback RateFallback
+		at       time.Time
+		want     *Money
+		errIs    error
+	}{
+		{name: "rates of the date", at: day, want: &Money{Units: 92, CurrencyCode: "EUR"}},
+		{name: "rates of the previous business day", at: day.AddDate(0, 0, 2), want: &Money{Units: 92, CurrencyCode: "EUR"}},
+		{name: "no rates for the date", fallback: FallbackError, at: day.AddDate(0, 0, 2), errIs: ErrRatesNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, con.Fallback(tt.fallback))
+
+			_, input, err := accountant.CostForModelInputAt("openai", "gpt-4o", "EUR", 100, tt.at)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				_, err = accountant.CostForUsageAt("openai", "gpt-4o", "EUR", Usage{InputTokens: 100}, tt.at)
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, input)
+
+			_, output, err := accountant.CostForModelOutputAt("openai", "gpt-4o", "EUR", 100, tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, output)
+
+			breakdown, err := accountant.CostForUsageAt("openai", "gpt-4o", "EUR", Usage{InputTokens: 100}, tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, breakdown.Total.Converted)
+
+			cached, err := accountant.CostForCacheUsageAt("openai", "gpt-4o", "EUR", CacheUsage{InputTokens: 100}, tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cached.Total.Converted)
+		})
+	}
+
+	// the methods without a time use the current rates
+	assert.NoError(t, con.Fallback(FallbackError))
+	_, input, err := accountant.CostForModelInput("openai", "gpt-4o", "EUR", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 85, CurrencyCode: "EUR"}, input)
+	breakdown, err := accountant.CostForUsage("openai", "gpt-4o", "EUR", Usage{InputTokens: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 85, CurrencyCode: "EUR"}, breakdown.Total.Converted)
+
+	// and so does a converter without dated rates
+	refreshing := NewRefreshingConverter("USD", &stubRateProvider{}, time.Minute, 0)
+	assert.NoError(t, refreshing.Rates(testRates))
+	_, input, err = NewAccountant(testModels, refreshing, false).CostForModelInputAt("openai", "gpt-4o", "EUR", 100, day)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 85, CurrencyCode: "EUR"}, input)
+}
+
+func Test_Model_EffectiveAt(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		model Model
+		at    time.Time
+		want  bool
+	}{
+		{name: "no window", model: Model{}, at: from, want: true},
+		{name: "before from", model: Model{EffectiveFrom: &from}, at: from.Add(-time.Second), want: false},
+		{name: "at from", model: Model{EffectiveFrom: &from}, at: from, want: true},
+		{name: "before to", model: Model{EffectiveTo: &to}, at: to.Add(-time.Second), want: true},
+		{name: "at to", model: Model{EffectiveTo: &to}, at: to, want: false},
+		{name: "inside window", model: Model{EffectiveFrom: &from, EffectiveTo: &to}, at: from.AddDate(0, 1, 0), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.model.EffectiveAt(tt.at))
+		})
+	}
+}
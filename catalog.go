@@ -0,0 +1,611 @@
+package aicost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidCatalog = errors.New("invalid pricing catalog")
+
+// CatalogFormat is the encoding of a pricing catalog
+type CatalogFormat string
+
+const (
+	CatalogJSON CatalogFormat = "json"
+	CatalogYAML CatalogFormat = "yaml"
+)
+
+// catalogDateLayout is the layout of Catalog.AsOf
+const catalogDateLayout = "2006-01-02"
+
+// Catalog is the content of a pricing catalog file
+type Catalog struct {
+	// Version identifies the catalog revision
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// AsOf is the date the prices were checked against the providers, formatted as 2006-01-02
+	AsOf   string  `json:"as_of,omitempty" yaml:"as_of,omitempty"`
+	Models []Model `json:"models" yaml:"models"`
+}
+
+// AsOfDate returns the parsed AsOf date, the zero time when unset
+func (c *Catalog) AsOfDate() (time.Time, error) {
+	if c.AsOf == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(catalogDateLayout, c.AsOf)
+}
+
+// MergeModels returns base with overrides applied, the overrides of a provider and model
+// replace all the base entries of that pair in place and the other overrides are appended
+func MergeModels(base []Model, overrides []Model) []Model {
+	byKey := make(map[string][]Model, len(overrides))
+	var keys []string
+	for _, m := range overrides {
+		key := m.Provider + "/" + m.Model
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], m)
+	}
+
+	merged := make([]Model, 0, len(base)+len(overrides))
+	emitted := make(map[string]bool, len(keys))
+	for _, m := range base {
+		key := m.Provider + "/" + m.Model
+		replacements, ok := byKey[key]
+		if !ok {
+			merged = append(merged, m)
+			continue
+		}
+		if !emitted[key] {
+			merged = append(merged, replacements...)
+			emitted[key] = true
+		}
+	}
+
+	for _, key := range keys {
+		if !emitted[key] {
+			merged = append(merged, byKey[key]...)
+		}
+	}
+
+	return merged
+}
+
+// CatalogError is an error at a position of a pricing catalog
+type CatalogError struct {
+	// File is the catalog path, empty when parsed from memory
+	File string
+	// Line and Column are 1-based, 0 when unknown
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *CatalogError) Error() string {
+	var position []string
+	if e.File != "" {
+		position = append(position, e.File)
+	}
+	if e.Line > 0 {
+		position = append(position, strconv.Itoa(e.Line))
+		if e.Column > 0 {
+			position = append(position, strconv.Itoa(e.Column))
+		}
+	}
+	if len(position) == 0 {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %v", strings.Join(position, ":"), e.Err)
+}
+
+func (e *CatalogError) Unwrap() []error {
+	return []error{ErrInvalidCatalog, e.Err}
+}
+
+// CatalogFormatForPath returns the catalog format matching the file extension
+func CatalogFormatForPath(path string) (CatalogFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return CatalogJSON, nil
+	case ".yaml", ".yml":
+		return CatalogYAML, nil
+	}
+
+	return "", fmt.Errorf("unknown catalog format for %s: %w", path, ErrInvalidCatalog)
+}
+
+// LoadCatalog reads and validates a pricing catalog file, the format is picked by the file extension
+func LoadCatalog(path string) (*Catalog, error) {
+	format, err := CatalogFormatForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog %s: %w", path, err)
+	}
+
+	catalog, err := ParseCatalog(data, format)
+	if err != nil {
+		var catalogErr *CatalogError
+		if errors.As(err, &catalogErr) {
+			catalogErr.File = path
+		}
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// LoadModels reads the models of a pricing catalog file
+func LoadModels(path string) ([]Model, error) {
+	catalog, err := LoadCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.Models, nil
+}
+
+// DecodeCatalog reads and validates a pricing catalog from r
+func DecodeCatalog(r io.Reader, format CatalogFormat) (*Catalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	return ParseCatalog(data, format)
+}
+
+// ParseCatalog parses and validates a pricing catalog
+// every price must be a valid Money and a provider/model pair can only be defined more than once
+// when the effective windows of its entries do not overlap
+func ParseCatalog(data []byte, format CatalogFormat) (*Catalog, error) {
+	var entries []catalogEntry
+	var err error
+
+	catalog := &Catalog{}
+	switch format {
+	case CatalogJSON:
+		entries, err = parseJSONCatalog(data, catalog)
+	case CatalogYAML:
+		entries, err = parseYAMLCatalog(data, catalog)
+	default:
+		return nil, fmt.Errorf("unknown catalog format %q: %w", format, ErrInvalidCatalog)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateCatalog(entries); err != nil {
+		return nil, err
+	}
+
+	catalog.Models = make([]Model, 0, len(entries))
+	for _, entry := range entries {
+		catalog.Models = append(catalog.Models, entry.model)
+	}
+
+	return catalog, nil
+}
+
+// catalogEntry is a model with its position in the catalog
+type catalogEntry struct {
+	model  Model
+	line   int
+	column int
+}
+
+func (e catalogEntry) errorf(format string, args ...any) error {
+	return &CatalogError{Line: e.line, Column: e.column, Err: fmt.Errorf(format, args...)}
+}
+
+func validateCatalog(entries []catalogEntry) error {
+	seen := make(map[string][]catalogEntry, len(entries))
+	owners := make(map[string]string, len(entries))
+	for i, entry := range entries {
+		if err := entry.model.Validate(); err != nil {
+			return entry.errorf("model %d: %w", i, err)
+		}
+
+		key := entry.model.Provider + "/" + entry.model.Model
+		for _, other := range seen[key] {
+			if effectiveOverlap(entry.model, other.model) {
+				return entry.errorf("duplicate model %s, first defined at %d:%d", key, other.line, other.column)
+			}
+		}
+		seen[key] = append(seen[key], entry)
+
+		// a name can only resolve to one model of a provider, see Counter.ResolveModel
+		for _, name := range append([]string{entry.model.Model}, entry.model.Aliases...) {
+			nameKey := entry.model.Provider + "/" + name
+			if owner, ok := owners[nameKey]; ok && owner != entry.model.Model {
+				return entry.errorf("name %s of %s is already used by %s", name, key, owner)
+			}
+			owners[nameKey] = entry.model.Model
+		}
+	}
+
+	return nil
+}
+
+// effectiveOverlap reports whether the effective windows of two models share any moment
+func effectiveOverlap(a, b Model) bool {
+	if a.EffectiveTo != nil && b.EffectiveFrom != nil && !b.EffectiveFrom.Before(*a.EffectiveTo) {
+		return false
+	}
+	if b.EffectiveTo != nil && a.EffectiveFrom != nil && !a.EffectiveFrom.Before(*b.EffectiveTo) {
+		return false
+	}
+
+	return true
+}
+
+// Validate checks that the model is identified and that all of its prices are valid Money in the currency of CostInput
+func (m Model) Validate() error {
+	if m.Provider == "" {
+		return errors.New("provider cannot be empty")
+	}
+	if m.Model == "" {
+		return errors.New("model cannot be empty")
+	}
+	if m.EffectiveFrom != nil && m.EffectiveTo != nil && !m.EffectiveFrom.Before(*m.EffectiveTo) {
+		return fmt.Errorf("%s effective_from must be before effective_to", m.Model)
+	}
+	if m.PerTokens < 0 {
+		return fmt.Errorf("%s per_tokens must not be negative: %d", m.Model, m.PerTokens)
+	}
+
+	prices := []namedPrice{
+		{"cost_input", &m.CostInput},
+		{"cost_output", &m.CostOutput},
+		{"cost_cached_input", m.CostCachedInput},
+		{"cost_cache_write", m.CostCacheWrite},
+		{"cost_reasoning", m.CostReasoning},
+		{"cost_image_input", m.CostImageInput},
+		{"cost_audio_input", m.CostAudioInput},
+		{"cost_audio_output", m.CostAudioOutput},
+	}
+	currency := m.CostInput.CurrencyCode
+	if err := validatePrices(m.Model, currency, prices); err != nil {
+		return err
+	}
+
+	thresholds := make(map[int64]bool, len(m.Tiers))
+	for i, tier := range m.Tiers {
+		if tier.AbovePromptTokens <= 0 {
+			return fmt.Errorf("%s tier %d: above_prompt_tokens must be greater than 0", m.Model, i)
+		}
+		if thresholds[tier.AbovePromptTokens] {
+			return fmt.Errorf("%s tier %d: duplicate above_prompt_tokens %d", m.Model, i, tier.AbovePromptTokens)
+		}
+		thresholds[tier.AbovePromptTokens] = true
+
+		prices := []namedPrice{
+			{"cost_input", &tier.CostInput},
+			{"cost_output", &tier.CostOutput},
+			{"cost_cached_input", tier.CostCachedInput},
+			{"cost_cache_write", tier.CostCacheWrite},
+			{"cost_image_input", tier.CostImageInput},
+			{"cost_audio_input", tier.CostAudioInput},
+			{"cost_audio_output", tier.CostAudioOutput},
+		}
+		if err := validatePrices(fmt.Sprintf("%s tier %d", m.Model, i), currency, prices); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// namedPrice is a price with the catalog field it was read from
+type namedPrice struct {
+	field string
+	price *Money
+}
+
+// validatePrices checks that the set prices are valid Money in currency, the prices of a model are added up together
+func validatePrices(owner string, currency string, prices []namedPrice) error {
+	for _, p := range prices {
+		if p.price == nil {
+			continue
+		}
+		if _, err := NewMoney(p.price.CurrencyCode, p.price.Units, p.price.Nanos); err != nil {
+			return fmt.Errorf("%s %s: %w", owner, p.field, err)
+		}
+		if p.price.CurrencyCode != currency {
+			return fmt.Errorf("%s %s: currency %s does not match the model currency %s", owner, p.field, p.price.CurrencyCode, currency)
+		}
+	}
+
+	return nil
+}
+
+func parseJSONCatalog(data []byte, catalog *Catalog) ([]catalogEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	jsonErr := func(err error) error {
+		line, column := jsonPosition(data, dec.InputOffset())
+		return &CatalogError{Line: line, Column: column, Err: err}
+	}
+
+	if err := expectJSONDelim(dec, '{'); err != nil {
+		return nil, jsonErr(err)
+	}
+
+	var entries []catalogEntry
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonErr(err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, jsonErr(fmt.Errorf("expected a key, got %v", tok))
+		}
+
+		switch key {
+		case "version":
+			if err := dec.Decode(&catalog.Version); err != nil {
+				return nil, jsonErr(err)
+			}
+		case "as_of":
+			if err := dec.Decode(&catalog.AsOf); err != nil {
+				return nil, jsonErr(err)
+			}
+			if _, err := time.Parse(catalogDateLayout, catalog.AsOf); err != nil {
+				return nil, jsonErr(fmt.Errorf("as_of: %w", err))
+			}
+		case "models":
+			if err := expectJSONDelim(dec, '['); err != nil {
+				return nil, jsonErr(err)
+			}
+			for dec.More() {
+				start := dec.InputOffset()
+				line, column := jsonPosition(data, start)
+				entry := catalogEntry{line: line, column: column}
+				if err := dec.Decode(&entry.model); err != nil {
+					return nil, entry.errorf("model %d: %w", len(entries), err)
+				}
+				if err := strictMoneyJSON(data[start:dec.InputOffset()]); err != nil {
+					return nil, entry.errorf("model %d: %w", len(entries), err)
+				}
+				entries = append(entries, entry)
+			}
+			if err := expectJSONDelim(dec, ']'); err != nil {
+				return nil, jsonErr(err)
+			}
+		default:
+			return nil, jsonErr(fmt.Errorf("unknown catalog field %q", key))
+		}
+	}
+
+	if err := expectJSONDelim(dec, '}'); err != nil {
+		return nil, jsonErr(err)
+	}
+	// nothing may follow the catalog object
+	end := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		line, column := jsonPosition(data, end)
+		return nil, &CatalogError{Line: line, Column: column, Err: errors.New("unexpected data after the catalog")}
+	}
+
+	return entries, nil
+}
+
+// strictMoneyJSON rejects unknown fields in the money objects of a JSON model and its tiers,
+// the decoder does not pass DisallowUnknownFields on to Money.UnmarshalJSON, which accepts them
+func strictMoneyJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.TrimLeft(data, " \t\r\n,"), &fields); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := bytes.TrimSpace(fields[key])
+		switch {
+		case key == "tiers":
+			var tiers []json.RawMessage
+			if err := json.Unmarshal(value, &tiers); err != nil {
+				return err
+			}
+			for i, tier := range tiers {
+				if err := strictMoneyJSON(tier); err != nil {
+					return fmt.Errorf("tier %d: %w", i, err)
+				}
+			}
+		case strings.HasPrefix(key, "cost_") && len(value) > 0 && value[0] == '{':
+			dec := json.NewDecoder(bytes.NewReader(value))
+			dec.DisallowUnknownFields()
+			var money moneyFields
+			if err := dec.Decode(&money); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+
+	return nil
+}
+
+// jsonPosition returns the line and column of the first value at or after offset,
+// skipping the whitespace and separators the decoder has not consumed yet
+func jsonPosition(data []byte, offset int64) (int, int) {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+
+	line, column := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+
+	return line, column
+}
+
+// yamlSyntaxLine matches the line yaml reports in a syntax error, e.g. "yaml: line 3: mapping values are not allowed"
+var yamlSyntaxLine = regexp.MustCompile(`^yaml: line (\d+):`)
+
+// yamlSyntaxErr returns a syntax error at the line yaml reports, the column is unknown,
+// and without a position when the message has no line
+func yamlSyntaxErr(err error) *CatalogError {
+	catalogErr := &CatalogError{Err: err}
+	if match := yamlSyntaxLine.FindStringSubmatch(err.Error()); match != nil {
+		catalogErr.Line, _ = strconv.Atoi(match[1])
+	}
+
+	return catalogErr
+}
+
+func parseYAMLCatalog(data []byte, catalog *Catalog) ([]catalogEntry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlSyntaxErr(err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, &CatalogError{Line: 1, Column: 1, Err: errors.New("empty catalog")}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, yamlErr(root, errors.New("expected a mapping"))
+	}
+
+	var entries []catalogEntry
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		switch key.Value {
+		case "version":
+			if err := value.Decode(&catalog.Version); err != nil {
+				return nil, yamlErr(value, err)
+			}
+		case "as_of":
+			if err := value.Decode(&catalog.AsOf); err != nil {
+				return nil, yamlErr(value, err)
+			}
+			if _, err := time.Parse(catalogDateLayout, catalog.AsOf); err != nil {
+				return nil, yamlErr(value, fmt.Errorf("as_of: %w", err))
+			}
+		case "models":
+			if value.Kind != yaml.SequenceNode {
+				return nil, yamlErr(value, errors.New("models must be a sequence"))
+			}
+			for _, item := range value.Content {
+				entry := catalogEntry{line: item.Line, column: item.Column}
+				if key := unknownYAMLField(item, reflect.TypeOf(entry.model)); key != nil {
+					return nil, yamlErr(key, fmt.Errorf("model %d: unknown field %q", len(entries), key.Value))
+				}
+				if err := item.Decode(&entry.model); err != nil {
+					return nil, entry.errorf("model %d: %w", len(entries), err)
+				}
+				entries = append(entries, entry)
+			}
+		default:
+			return nil, yamlErr(key, fmt.Errorf("unknown catalog field %q", key.Value))
+		}
+	}
+
+	return entries, nil
+}
+
+func yamlErr(node *yaml.Node, err error) error {
+	return &CatalogError{Line: node.Line, Column: node.Column, Err: err}
+}
+
+// unknownYAMLField returns the first key of a mapping in node that is not a field of what t decodes it into, nil when there is none
+// yaml.Node.Decode has no KnownFields, the nodes are walked instead so the key keeps its position in the file
+func unknownYAMLField(node *yaml.Node, t reflect.Type) *yaml.Node {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for _, item := range node.Content {
+			if key := unknownYAMLField(item, t.Elem()); key != nil {
+				return key
+			}
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				return key
+			}
+			if key := unknownYAMLField(value, field); key != nil {
+				return key
+			}
+		}
+	}
+
+	return nil
+}
+
+// yamlFields returns the types of the fields of a struct by their YAML key, including the fields of inlined structs
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+		case strings.Contains(options, "inline"):
+			for key, field := range yamlFields(f.Type) {
+				fields[key] = field
+			}
+		case name == "":
+			fields[strings.ToLower(f.Name)] = f.Type
+		default:
+			fields[name] = f.Type
+		}
+	}
+
+	return fields
+}
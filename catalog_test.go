@@ -0,0 +1,548 @@
+package aicost
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCatalogJSON = `{
+  "models": [
+    {
+      "provider": "openai",
+      "model": "gpt-4",
+      "version": "1",
+      "cost_input": {"units": 0, "nanos": 30000, "currency_code": "USD"},
+      "cost_output": {"units": 0, "nanos": 60000, "currency_code": "USD"}
+    },
+    {
+      "provider": "anthropic",
+      "model": "claude-3",
+      "version": "1",
+      "cost_input": {"units": 0, "nanos": 3000, "currency_code": "USD"},
+      "cost_output": {"units": 0, "nanos": 15000, "currency_code": "USD"},
+      "cost_cached_input": {"units": 0, "nanos": 300, "currency_code": "USD"}
+    }
+  ]
+}`
+
+const testCatalogYAML = `models:
+  - provider: openai
+    model: gpt-4
+    version: "1"
+    cost_input: {units: 0, nanos: 30000, currency_code: USD}
+    cost_output: {units: 0, nanos: 60000, currency_code: USD}
+  - provider: anthropic
+    model: claude-3
+    version: "1"
+    cost_input: {units: 0, nanos: 3000, currency_code: USD}
+    cost_output: {units: 0, nanos: 15000, currency_code: USD}
+    cost_cached_input: {units: 0, nanos: 300, currency_code: USD}
+`
+
+var testCatalogModels = []Model{
+	{
+		Provider:   "openai",
+		Model:      "gpt-4",
+		Version:    "1",
+		CostInput:  Money{Units: 0, Nanos: 30000, CurrencyCode: "USD"},
+		CostOutput: Money{Units: 0, Nanos: 60000, CurrencyCode: "USD"},
+	},
+	{
+		Provider:        "anthropic",
+		Model:           "claude-3",
+		Version:         "1",
+		CostInput:       Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"},
+		CostOutput:      Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+		CostCachedInput: &Money{Units: 0, Nanos: 300, CurrencyCode: "USD"},
+	},
+}
+
+func Test_ParseCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		format  CatalogFormat
+		want    []Model
+		wantErr string
+	}{
+		{
+			name:   "valid json",
+			data:   testCatalogJSON,
+			format: CatalogJSON,
+			want:   testCatalogModels,
+		},
+		{
+			name:   "valid yaml",
+			data:   testCatalogYAML,
+			format: CatalogYAML,
+			want:   testCatalogModels,
+		},
+		{
+			name: "json decimal prices",
+			data: `{"models": [
+  {"provider": "openai", "model": "gpt-4", "version": "1",
+   "cost_input": "USD 0.00003", "cost_output": "USD 0.00006"},
+  {"provider": "anthropic", "model": "claude-3", "version": "1",
+   "cost_input": "USD 0.000003", "cost_output": "USD 0.000015", "cost_cached_input": "USD 0.0000003"}
+]}`,
+			format: CatalogJSON,
+			want:   testCatalogModels,
+		},
+		{
+			name: "yaml decimal prices",
+			data: `models:
+  - {provider: openai, model: gpt-4, version: "1", cost_input: USD 0.00003, cost_output: USD 0.00006}
+  - provider: anthropic
+    model: claude-3
+    version: "1"
+    cost_input: USD 0.000003
+    cost_output: USD 0.000015
+    cost_cached_input: USD 0.0000003
+`,
+			format: CatalogYAML,
+			want:   testCatalogModels,
+		},
+		{
+			name: "json invalid money",
+			data: `{"models": [
+  {"provider": "openai", "model": "gpt-4",
+   "cost_input": {"units": 1, "nanos": -5, "currency_code": "USD"},
+   "cost_output": {"units": 0, "nanos": 60000, "currency_code": "USD"}}
+]}`,
+			format:  CatalogJSON,
+			wantErr: "2:3: model 0: gpt-4 cost_input: units and nanos must have the same sign",
+		},
+		{
+			name:    "json mixed currencies",
+			data:    `{"models": [{"provider": "openai", "model": "gpt-4", "cost_input": "USD 1", "cost_output": "EUR 1"}]}`,
+			format:  CatalogJSON,
+			wantErr: "1:13: model 0: gpt-4 cost_output: currency EUR does not match the model currency USD",
+		},
+		{
+			name: "yaml tier in another currency",
+			data: `models:
+  - provider: google
+    model: gemini-pro
+    cost_input: USD 0.000001
+    cost_output: USD 0.00001
+    tiers:
+      - above_prompt_tokens: 200000
+        cost_input: USD 0.000002
+        cost_output: EUR 0.000015
+`,
+			format:  CatalogYAML,
+			wantErr: "2:5: model 0: gemini-pro tier 0 cost_output: currency EUR does not match the model currency USD",
+		},
+		{
+			name: "json duplicate model",
+			data: `{"models": [
+  {"provider": "openai", "model": "gpt-4",
+   "cost_input": {"units": 0, "nanos": 1, "currency_code": "USD"},
+   "cost_output": {"units": 0, "nanos": 1, "currency_code": "USD"}},
+  {"provider": "openai", "model": "gpt-4",
+   "cost_input": {"units": 0, "nanos": 2, "currency_code": "USD"},
+   "cost_output": {"units": 0, "nanos": 2, "currency_code": "USD"}}
+]}`,
+			format:  CatalogJSON,
+			wantErr: "5:3: duplicate model openai/gpt-4, first defined at 2:3",
+		},
+		{
+			name:    "json unknown model field",
+			data:    `{"models": [{"provider": "openai", "model": "gpt-4", "cost_inptu": {}}]}`,
+			format:  CatalogJSON,
+			wantErr: `1:13: model 0: json: unknown field "cost_inptu"`,
+		},
+		{
+			name:    "json unknown money field",
+			data:    `{"models": [{"provider": "openai", "model": "gpt-4", "cost_input": {"units": 0, "nanoz": 5, "currency_code": "USD"}, "cost_output": "USD 0.00001"}]}`,
+			format:  CatalogJSON,
+			wantErr: `1:13: model 0: cost_input: json: unknown field "nanoz"`,
+		},
+		{
+			name: "json unknown tier money field",
+			data: `{"models": [{"provider": "google", "model": "gemini-pro", "cost_input": "USD 0.000001", "cost_output": "USD 0.00001",
+  "tiers": [{"name": "long", "above_prompt_tokens": 200000, "cost_input": "USD 0.000002", "cost_output": {"units": 0, "nanos": 15000, "currency": "USD"}}]}]}`,
+			format:  CatalogJSON,
+			wantErr: `1:13: model 0: tier 0: cost_output: json: unknown field "currency"`,
+		},
+		{
+			name:    "yaml unknown money field",
+			data:    "models:\n  - provider: openai\n    model: gpt-4\n    cost_input: {units: 0, nanoz: 5, currency_code: USD}\n    cost_output: USD 0.00001\n",
+			format:  CatalogYAML,
+			wantErr: `4:28: model 0: unknown field "nanoz"`,
+		},
+		{
+			name:    "json unknown catalog field",
+			data:    `{"prices": []}`,
+			format:  CatalogJSON,
+			wantErr: `unknown catalog field "prices"`,
+		},
+		{
+			name: "yaml missing currency",
+			data: `models:
+  - provider: openai
+    model: gpt-4
+    cost_input: {units: 0, nanos: 1, currency_code: USD}
+    cost_output: {units: 0, nanos: 1}
+`,
+			format:  CatalogYAML,
+			wantErr: "2:5: model 0: gpt-4 cost_output: currency code cannot be empty",
+		},
+		{
+			name: "yaml unknown model field",
+			data: `models:
+  - provider: openai
+    model: gpt-4
+    cost_inptu: {units: 0, nanos: 1, currency_code: USD}
+`,
+			format:  CatalogYAML,
+			wantErr: `4:5: model 0: unknown field "cost_inptu"`,
+		},
+		{
+			name: "yaml unknown tier field at its line",
+			data: `version: "1"
+models:
+  - provider: openai
+    model: gpt-4
+    cost_input: USD 0.00003
+    cost_output: USD 0.00006
+  - provider: google
+    model: gemini-pro
+    cost_input: USD 0.000001
+    cost_output: USD 0.00001
+    tiers:
+      - above_prompt_tokens: 200000
+        bogus: true
+        cost_input: USD 0.000002
+        cost_output: USD 0.000015
+`,
+			format:  CatalogYAML,
+			wantErr: `13:9: model 1: unknown field "bogus"`,
+		},
+		{
+			name:    "json trailing data",
+			data:    `{"models": []} trailing`,
+			format:  CatalogJSON,
+			wantErr: "1:16: unexpected data after the catalog",
+		},
+		{
+			name:    "json second object",
+			data:    `{"models": []} {}`,
+			format:  CatalogJSON,
+			wantErr: "1:16: unexpected data after the catalog",
+		},
+		{
+			name: "yaml duplicate model",
+			data: `models:
+  - provider: openai
+    model: gpt-4
+    cost_input: {units: 0, nanos: 1, currency_code: USD}
+    cost_output: {units: 0, nanos: 1, currency_code: USD}
+  - provider: openai
+    model: gpt-4
+    cost_input: {units: 0, nanos: 2, currency_code: USD}
+    cost_output: {units: 0, nanos: 2, currency_code: USD}
+`,
+			format:  CatalogYAML,
+			wantErr: "6:5: duplicate model openai/gpt-4, first defined at 2:5",
+		},
+		{
+			name:    "unknown format",
+			data:    testCatalogJSON,
+			format:  CatalogFormat("toml"),
+			wantErr: `unknown catalog format "toml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCatalog([]byte(tt.data), tt.format)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidCatalog)
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Models)
+		})
+	}
+}
+
+func Test_Model_Validate(t *testing.T) {
+	valid := testCatalogModels[0]
+
+	tiered := valid
+	tiered.Tiers = []PriceTier{
+		{
+			Name:              "long-context",
+			AbovePromptTokens: 200000,
+			CostInput:         Money{Units: 0, Nanos: 60000, CurrencyCode: "USD"},
+			CostOutput:        Money{Units: 0, Nanos: 120000, CurrencyCode: "USD"},
+		},
+	}
+
+	badTier := tiered
+	badTier.Tiers = []PriceTier{tiered.Tiers[0], tiered.Tiers[0]}
+
+	noProvider := valid
+	noProvider.Provider = ""
+
+	negativePerTokens := valid
+	negativePerTokens.PerTokens = -1000
+
+	assert.NoError(t, valid.Validate())
+	assert.NoError(t, tiered.Validate())
+	assert.ErrorContains(t, badTier.Validate(), "duplicate above_prompt_tokens 200000")
+	assert.ErrorContains(t, noProvider.Validate(), "provider cannot be empty")
+	assert.ErrorContains(t, negativePerTokens.Validate(), "per_tokens must not be negative")
+}
+
+func Test_LoadModels(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "models.json")
+	yamlPath := filepath.Join(dir, "models.yml")
+	badPath := filepath.Join(dir, "bad.json")
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(testCatalogJSON), 0o600))
+	assert.NoError(t, os.WriteFile(yamlPath, []byte(testCatalogYAML), 0o600))
+	assert.NoError(t, os.WriteFile(badPath, []byte(`{"models": [`), 0o600))
+
+	got, err := LoadModels(jsonPath)
+	assert.NoError(t, err)
+	assert.Equal(t, testCatalogModels, got)
+
+	got, err = LoadModels(yamlPath)
+	assert.NoError(t, err)
+	assert.Equal(t, testCatalogModels, got)
+
+	_, err = LoadModels(badPath)
+	assert.ErrorIs(t, err, ErrInvalidCatalog)
+	assert.True(t, strings.HasPrefix(err.Error(), badPath+":1:"), err.Error())
+
+	_, err = LoadModels(filepath.Join(dir, "models.toml"))
+	assert.ErrorIs(t, err, ErrInvalidCatalog)
+
+	_, err = LoadModels(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_ParseCatalog_Header(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		format      CatalogFormat
+		wantVersion string
+		wantAsOf    time.Time
+		wantErr     string
+	}{
+		{
+			name:        "json header",
+			data:        `{"version": "2", "as_of": "2025-03-01", "models": []}`,
+			format:      CatalogJSON,
+			wantVersion: "2",
+			wantAsOf:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "yaml header",
+			data:        "version: \"2\"\nas_of: \"2025-03-01\"\nmodels: []\n",
+			format:      CatalogYAML,
+			wantVersion: "2",
+			wantAsOf:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "json invalid as_of",
+			data:    `{"as_of": "March 2025", "models": []}`,
+			format:  CatalogJSON,
+			wantErr: "as_of: parsing time",
+		},
+		{
+			name:    "yaml syntax error at its line",
+			data:    "version: \"2\"\nmodels:\n  - provider: openai\n    model: gpt-4: turbo\n",
+			format:  CatalogYAML,
+			wantErr: "4: yaml: line 4: mapping values are not allowed",
+		},
+		{
+			name:    "yaml invalid as_of",
+			data:    "as_of: March 2025\nmodels: []\n",
+			format:  CatalogYAML,
+			wantErr: "1:8: as_of: parsing time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCatalog([]byte(tt.data), tt.format)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, got.Version)
+
+			asOf, err := got.AsOfDate()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAsOf, asOf)
+		})
+	}
+}
+
+func Test_MergeModels(t *testing.T) {
+	base := []Model{
+		{Provider: "openai", Model: "gpt-4", Version: "base"},
+		{Provider: "anthropic", Model: "claude-3", Version: "base"},
+	}
+
+	tests := []struct {
+		name      string
+		overrides []Model
+		want      []Model
+	}{
+		{
+			name:      "no overrides",
+			overrides: nil,
+			want:      base,
+		},
+		{
+			name: "replace in place and append new",
+			overrides: []Model{
+				{Provider: "openai", Model: "gpt-5", Version: "override"},
+				{Provider: "openai", Model: "gpt-4", Version: "override"},
+			},
+			want: []Model{
+				{Provider: "openai", Model: "gpt-4", Version: "override"},
+				{Provider: "anthropic", Model: "claude-3", Version: "base"},
+				{Provider: "openai", Model: "gpt-5", Version: "override"},
+			},
+		},
+		{
+			name: "provider is part of the key",
+			overrides: []Model{
+				{Provider: "azure", Model: "gpt-4", Version: "override"},
+			},
+			want: []Model{
+				{Provider: "openai", Model: "gpt-4", Version: "base"},
+				{Provider: "anthropic", Model: "claude-3", Version: "base"},
+				{Provider: "azure", Model: "gpt-4", Version: "override"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MergeModels(base, tt.overrides))
+		})
+	}
+}
+
+func Test_ParseCatalog_EffectiveWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantLen int
+		wantErr string
+	}{
+		{
+			name: "consecutive windows",
+			data: `models:
+  - provider: openai
+    model: o3
+    cost_input: {units: 0, nanos: 10000, currency_code: USD}
+    cost_output: {units: 0, nanos: 40000, currency_code: USD}
+    effective_to: 2025-06-01T00:00:00Z
+  - provider: openai
+    model: o3
+    cost_input: {units: 0, nanos: 2000, currency_code: USD}
+    cost_output: {units: 0, nanos: 8000, currency_code: USD}
+    effective_from: 2025-06-01T00:00:00Z
+`,
+			wantLen: 2,
+		},
+		{
+			name: "overlapping windows",
+			data: `models:
+  - provider: openai
+    model: o3
+    cost_input: {units: 0, nanos: 10000, currency_code: USD}
+    cost_output: {units: 0, nanos: 40000, currency_code: USD}
+    effective_to: 2025-06-02T00:00:00Z
+  - provider: openai
+    model: o3
+    cost_input: {units: 0, nanos: 2000, currency_code: USD}
+    cost_output: {units: 0, nanos: 8000, currency_code: USD}
+    effective_from: 2025-06-01T00:00:00Z
+`,
+			wantErr: "7:5: duplicate model openai/o3, first defined at 2:5",
+		},
+		{
+			name: "empty window",
+			data: `models:
+  - provider: openai
+    model: o3
+    cost_input: {units: 0, nanos: 10000, currency_code: USD}
+    cost_output: {units: 0, nanos: 40000, currency_code: USD}
+    effective_from: 2025-06-01T00:00:00Z
+    effective_to: 2025-06-01T00:00:00Z
+`,
+			wantErr: "o3 effective_from must be before effective_to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCatalog([]byte(tt.data), CatalogYAML)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, got.Models, tt.wantLen)
+		})
+	}
+}
+
+func Test_ParseCatalog_Aliases(t *testing.T) {
+	data := `models:
+  - provider: anthropic
+    model: claude-3-5-sonnet
+    aliases: [claude-3-5-sonnet-latest]
+    cost_input: {units: 0, nanos: 3000, currency_code: USD}
+    cost_output: {units: 0, nanos: 15000, currency_code: USD}
+  - provider: anthropic
+    model: claude-3-7-sonnet
+    aliases: [claude-3-5-sonnet-latest]
+    cost_input: {units: 0, nanos: 3000, currency_code: USD}
+    cost_output: {units: 0, nanos: 15000, currency_code: USD}
+`
+
+	_, err := ParseCatalog([]byte(data), CatalogYAML)
+	assert.ErrorContains(t, err, "7:5: name claude-3-5-sonnet-latest of anthropic/claude-3-7-sonnet is already used by claude-3-5-sonnet")
+}
+
+func Test_CatalogError_Error(t *testing.T) {
+	err := errors.New("bad price")
+
+	tests := []struct {
+		name string
+		err  *CatalogError
+		want string
+	}{
+		{name: "line and column", err: &CatalogError{File: "models.yaml", Line: 2, Column: 5, Err: err}, want: "models.yaml:2:5: bad price"},
+		{name: "line only", err: &CatalogError{File: "models.yaml", Line: 4, Err: err}, want: "models.yaml:4: bad price"},
+		{name: "in memory", err: &CatalogError{Line: 2, Column: 5, Err: err}, want: "2:5: bad price"},
+		{name: "file without position", err: &CatalogError{File: "models.yaml", Err: err}, want: "models.yaml: bad price"},
+		{name: "no position", err: &CatalogError{Err: err}, want: "bad price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.err.Error())
+		})
+	}
+}
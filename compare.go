@@ -0,0 +1,106 @@
+package aicost
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Cmp compares m and n and returns -1 when m is less than n, 0 when they are equal and +1 when m is greater
+// amounts are compared by their total in nanos, so units and nanos with different signs compare by their sum
+// like Add, amounts in different currencies and an empty or unknown currency are an error
+func (m *Money) Cmp(n *Money) (int, error) {
+	a, b, err := operands(m, n)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.Cmp(b), nil
+}
+
+// Equal reports whether m and n are the same amount
+func (m *Money) Equal(n *Money) (bool, error) {
+	c, err := m.Cmp(n)
+	if err != nil {
+		return false, err
+	}
+
+	return c == 0, nil
+}
+
+// LessThan reports whether m is less than n
+func (m *Money) LessThan(n *Money) (bool, error) {
+	c, err := m.Cmp(n)
+	if err != nil {
+		return false, err
+	}
+
+	return c < 0, nil
+}
+
+// IsZero reports whether m is zero, in any currency, m is normalized
+func (m *Money) IsZero() bool {
+	return m.totalNanos().Sign() == 0
+}
+
+// IsNegative reports whether m is less than zero, m is normalized
+func (m *Money) IsNegative() bool {
+	return m.totalNanos().Sign() < 0
+}
+
+// Min returns a copy of the smaller of m and n, m when they are equal
+func (m *Money) Min(n *Money) (*Money, error) {
+	c, err := m.Cmp(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if c > 0 {
+		smaller := *n
+		return &smaller, nil
+	}
+	smaller := *m
+
+	return &smaller, nil
+}
+
+// Max returns a copy of the larger of m and n, m when they are equal
+func (m *Money) Max(n *Money) (*Money, error) {
+	c, err := m.Cmp(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if c < 0 {
+		larger := *n
+		return &larger, nil
+	}
+	larger := *m
+
+	return &larger, nil
+}
+
+// SortMoney sorts amounts in increasing order, equal amounts keep their order
+// the amounts are normalized and must all have the same valid currency,
+// otherwise they are left as they are and an error is returned
+func SortMoney(amounts []*Money) error {
+	for i := 1; i < len(amounts); i++ {
+		if _, _, err := operands(amounts[i], amounts[0]); err != nil {
+			return fmt.Errorf("amount %d: %w", i, err)
+		}
+	}
+
+	sort.SliceStable(amounts, func(i, j int) bool {
+		return amounts[i].totalNanos().Cmp(amounts[j].totalNanos()) < 0
+	})
+
+	return nil
+}
+
+// sameCurrency returns an error when m and n have different currencies
+func sameCurrency(m, n *Money) error {
+	if m.CurrencyCode != n.CurrencyCode {
+		return fmt.Errorf("currency codes do not match: %s != %s", m.CurrencyCode, n.CurrencyCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package aicost
+
+import (
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Money_Cmp(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       Money
+		n       Money
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "less",
+			m:    Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			n:    Money{Units: 1, Nanos: 1, CurrencyCode: "USD"},
+			want: -1,
+		},
+		{
+			name: "equal",
+			m:    Money{Units: 2, Nanos: 500000000, CurrencyCode: "USD"},
+			n:    Money{Units: 2, Nanos: 500000000, CurrencyCode: "USD"},
+			want: 0,
+		},
+		{
+			name: "greater",
+			m:    Money{Units: 0, Nanos: 3, CurrencyCode: "USD"},
+			n:    Money{Units: 0, Nanos: 2, CurrencyCode: "USD"},
+			want: 1,
+		},
+		{
+			name: "negative nanos only",
+			m:    Money{Units: 0, Nanos: -500000000, CurrencyCode: "USD"},
+			n:    Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+			want: -1,
+		},
+		{
+			name: "negative units and nanos",
+			m:    Money{Units: -1, Nanos: -500000000, CurrencyCode: "USD"},
+			n:    Money{Units: -1, Nanos: 0, CurrencyCode: "USD"},
+			want: -1,
+		},
+		{
+			name: "mixed signs compare by their sum",
+			m:    Money{Units: 1, Nanos: -500000000, CurrencyCode: "USD"},
+			n:    Money{Units: 0, Nanos: 500000000, CurrencyCode: "USD"},
+			want: 0,
+		},
+		{
+			name:    "currency mismatch",
+			m:       Money{Units: 1, CurrencyCode: "USD"},
+			n:       Money{Units: 1, CurrencyCode: "EUR"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.Cmp(&tt.n)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			equal, err := tt.m.Equal(&tt.n)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want == 0, equal)
+
+			less, err := tt.m.LessThan(&tt.n)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want < 0, less)
+		})
+	}
+}
+
+func Test_Money_Cmp_CurrencyMismatch(t *testing.T) {
+	usd := &Money{Units: 1, CurrencyCode: "USD"}
+	eur := &Money{Units: 1, CurrencyCode: "EUR"}
+
+	_, err := usd.Equal(eur)
+	assert.Error(t, err)
+	_, err = usd.LessThan(eur)
+	assert.Error(t, err)
+	_, err = usd.Min(eur)
+	assert.Error(t, err)
+	_, err = usd.Max(eur)
+	assert.Error(t, err)
+}
+
+func Test_Money_IsZero_IsNegative(t *testing.T) {
+	tests := []struct {
+		name         string
+		money        Money
+		wantZero     bool
+		wantNegative bool
+	}{
+		{name: "zero", money: Money{CurrencyCode: "USD"}, wantZero: true},
+		{name: "positive", money: Money{Units: 1, CurrencyCode: "USD"}},
+		{name: "positive nanos", money: Money{Nanos: 1, CurrencyCode: "USD"}},
+		{name: "negative", money: Money{Units: -1, Nanos: -1, CurrencyCode: "USD"}, wantNegative: true},
+		{name: "negative nanos", money: Money{Nanos: -1, CurrencyCode: "USD"}, wantNegative: true},
+		{name: "mixed signs summing to negative", money: Money{Units: -1, Nanos: 1, CurrencyCode: "USD"}, wantNegative: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantZero, tt.money.IsZero())
+			assert.Equal(t, tt.wantNegative, tt.money.IsNegative())
+		})
+	}
+}
+
+func Test_Money_Min_Max(t *testing.T) {
+	small := &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"}
+	large := &Money{Units: 1, Nanos: 1, CurrencyCode: "USD"}
+
+	got, err := small.Min(large)
+	assert.NoError(t, err)
+	assert.Equal(t, small, got)
+	assert.NotSame(t, small, got)
+
+	got, err = large.Min(small)
+	assert.NoError(t, err)
+	assert.Equal(t, small, got)
+
+	got, err = small.Max(large)
+	assert.NoError(t, err)
+	assert.Equal(t, large, got)
+	assert.NotSame(t, large, got)
+
+	got, err = large.Max(small)
+	assert.NoError(t, err)
+	assert.Equal(t, large, got)
+}
+
+func Test_SortMoney(t *testing.T) {
+	amounts := []*Money{
+		{Units: 2, Nanos: 0, CurrencyCode: "USD"},
+		{Units: -1, Nanos: -500000000, CurrencyCode: "USD"},
+		{Units: 0, Nanos: 1, CurrencyCode: "USD"},
+		{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+		{Units: 1, Nanos: -500000000, CurrencyCode: "USD"},
+		{Units: 0, Nanos: 500000000, CurrencyCode: "USD"},
+	}
+
+	assert.NoError(t, SortMoney(amounts))
+	assert.Equal(t, []*Money{
+		{Units: -1, Nanos: -500000000, CurrencyCode: "USD"},
+		{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+		{Units: 0, Nanos: 1, CurrencyCode: "USD"},
+		{Units: 1, Nanos: -500000000, CurrencyCode: "USD"},
+		{Units: 0, Nanos: 500000000, CurrencyCode: "USD"},
+		{Units: 2, Nanos: 0, CurrencyCode: "USD"},
+	}, amounts)
+
+	mixed := []*Money{
+		{Units: 2, CurrencyCode: "USD"},
+		{Units: 1, CurrencyCode: "EUR"},
+	}
+	assert.Error(t, SortMoney(mixed))
+	assert.Equal(t, "USD", mixed[0].CurrencyCode)
+
+	assert.NoError(t, SortMoney(nil))
+}
+
+func Test_Money_Cmp_Property(t *testing.T) {
+	// Cmp agrees with the sign of the exact difference
+	agrees := func(a, b quickMoney) bool {
+		b.CurrencyCode = a.CurrencyCode
+		got, err := a.Cmp(&b.Money)
+		if err != nil {
+			return false
+		}
+		diff := exactNanos(a.Money)
+		return got == diff.Sub(diff, exactNanos(b.Money)).Sign()
+	}
+	assert.NoError(t, quick.Check(agrees, nil))
+
+	// Min and Max return both amounts
+	minMax := func(a, b quickMoney) bool {
+		b.CurrencyCode = a.CurrencyCode
+		lo, errLo := a.Min(&b.Money)
+		hi, errHi := a.Max(&b.Money)
+		if errLo != nil || errHi != nil {
+			return false
+		}
+		less, _ := hi.LessThan(lo)
+		sum := exactNanos(*lo)
+		return !less && sum.Add(sum, exactNanos(*hi)).Cmp(new(big.Int).Add(exactNanos(a.Money), exactNanos(b.Money))) == 0
+	}
+	assert.NoError(t, quick.Check(minMax, nil))
+}
@@ -2,6 +2,12 @@ package aicost
 
 import (
 	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Converter defines the methods that any type of currency converter must implement.
@@ -10,13 +16,33 @@ type Converter interface {
 	Rates(rates map[string]float64) error
 }
 
+// DatedConverter is implemented by converters that also convert with the rates in effect at a given time,
+// the *At cost methods of Counter use it when their converter implements it, see converter.ConvertAt
+type DatedConverter interface {
+	ConvertAt(amount Money, toCurrency string, at time.Time) (*Money, error)
+}
+
 // converter holds the conversion rates and scale factors for different currencies.
 type converter struct {
 	baseCurrency string
-	rates        map[string]float64
+
+	// mu guards the rates, the fallback and the graphs built from them
+	mu    sync.RWMutex
+	rates map[string]float64
+	// pairs are direct quotes between two currencies, see PairRates
+	pairs []PairRate
+	// history holds the base rates by date, see RatesAt
+	history  map[string]map[string]float64
+	fallback RateFallback
+	// graphs holds the rate graph of the current rates under "" and of the dated rates under their date,
+	// built on first use and dropped when the rates or pairs they are built from change
+	graphs map[string]*rateGraph
+	// datedGraphs holds the dates of the stored dated graphs, oldest first, see maxDatedGraphs
+	datedGraphs []string
 }
 
 var _ Converter = (*converter)(nil)
+var _ DatedConverter = (*converter)(nil)
 
 // NewConverter initializes a new converter struct with default rates.
 func NewConverter(baseCurrency string, rates map[string]float64) *converter {
@@ -26,61 +52,110 @@ func NewConverter(baseCurrency string, rates map[string]float64) *converter {
 	}
 }
 
-// Rates sets the conversion rates for the converter.
+// Rates sets the conversion rates for the converter, a copy of rates is kept.
 func (c *converter) Rates(rates map[string]float64) error {
+	if err := validateRates(rates); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rates = maps.Clone(rates)
+	delete(c.graphs, currentRates)
+
+	return nil
+}
+
+// validateRates checks the currencies and the values of rates against the base currency
+func validateRates(rates map[string]float64) error {
 	if len(rates) == 0 {
 		return fmt.Errorf("conversion rates cannot be empty")
 	}
 
 	for cur, rate := range rates {
-		if rate <= 0 {
-			return fmt.Errorf("conversion rate %s must be greater than 0: %f", cur, rate)
+		if err := validateCurrency(cur); err != nil {
+			return fmt.Errorf("conversion rate %s: %w", cur, err)
+		}
+		if _, err := rateRat(cur, rate); err != nil {
+			return err
 		}
 	}
 
-	c.rates = rates
-
 	return nil
 }
 
 // Convert takes an amount in a source currency and converts it to the target currency
 // it returns the converted amount in the target currency
+// both currencies must be known, see LookupCurrency
+// the rates are used as the decimals they are written with, e.g. 0.85 is exactly 85/100,
+// and the amount is rounded to nanos once, half away from zero, even between two currencies other than the base
+// so the result is off by at most half a nano from the exact product of the amount and the rates,
+// and converting an amount of A to B and back with the base rates, or a pair rate quoted one way only,
+// returns it to within half a nano of A plus half a nano of B worth in A, 0.5 + 0.5 × rate(A)/rate(B) nanos of A
+// see ConvertPath for the rates the conversion used
 func (c *converter) Convert(providedMoney Money, toCurrency string) (*Money, error) {
+	conversion, err := c.ConvertPath(providedMoney, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversion.Result, nil
+}
+
+// ConvertPath converts an amount like Convert, with the same rounding and round-trip bounds,
+// and returns the path of currencies it took with the rate of every leg
+// a direct pair rate is used when there is one, otherwise the path with the fewest legs through the base and pair rates
+func (c *converter) ConvertPath(providedMoney Money, toCurrency string) (*Conversion, error) {
+	return c.convertPath(currentRates, providedMoney, toCurrency)
+}
+
+// convertPath converts an amount with the base rates stored under key, see graph, and the pair rates
+func (c *converter) convertPath(key string, providedMoney Money, toCurrency string) (*Conversion, error) {
+	if err := validateCurrency(providedMoney.CurrencyCode); err != nil {
+		return nil, fmt.Errorf("source currency: %w", err)
+	}
+	if err := validateCurrency(toCurrency); err != nil {
+		return nil, fmt.Errorf("target currency: %w", err)
+	}
+
 	// if the source and target currencies are the same, return the amount as is
 	if providedMoney.CurrencyCode == toCurrency {
-		return &providedMoney, nil
+		return &Conversion{
+			Amount: providedMoney,
+			Result: &providedMoney,
+			Path:   []string{toCurrency},
+			Rate:   big.NewRat(1, 1),
+		}, nil
 	}
 
-	var baseAmount = &providedMoney
-
-	// if the base currency is not the same as the source currency
-	// convert the amount to the base currency first
-	if providedMoney.CurrencyCode != c.baseCurrency {
-		var err error
-		baseAmount, err = c.convertToBase(providedMoney)
-		if err != nil {
-			return nil, fmt.Errorf("error converting to base currency: %w", err)
-		}
+	graph, err := c.graph(key)
+	if err != nil {
+		return nil, err
 	}
 
-	if baseAmount.CurrencyCode == toCurrency {
-		return baseAmount, nil
+	legs := graph.path(providedMoney.CurrencyCode, toCurrency)
+	if legs == nil {
+		return nil, fmt.Errorf("no conversion rate path from %s to %s", providedMoney.CurrencyCode, toCurrency)
 	}
 
-	// convert from the base currency to the target currency
-	// if the target currency is the base currency, flip the rate.
-	targetRate, ok := c.rates[toCurrency]
-	if !ok {
-		return nil, fmt.Errorf("conversion rate for currency %s not found", toCurrency)
+	conversion := &Conversion{
+		Amount: providedMoney,
+		Path:   []string{providedMoney.CurrencyCode},
+		Legs:   legs,
+		Rate:   big.NewRat(1, 1),
+	}
+	for _, leg := range legs {
+		conversion.Path = append(conversion.Path, leg.To)
+		conversion.Rate.Mul(conversion.Rate, leg.Rate)
 	}
 
-	converted, err := baseAmount.TimesFloat(targetRate)
+	conversion.Result, err = convertMoney(providedMoney, conversion.Rate, toCurrency)
 	if err != nil {
-		return nil, fmt.Errorf("error converting to target currency: %w", err)
+		return nil, fmt.Errorf("error converting %s to %s: %w", providedMoney.CurrencyCode, toCurrency, err)
 	}
-	converted.CurrencyCode = toCurrency
 
-	return converted, nil
+	return conversion, nil
 }
 
 // convertToBase is a helper function that converts an amount to the base currency.
@@ -89,19 +164,60 @@ func (c *converter) convertToBase(amount Money) (*Money, error) {
 		return &amount, nil
 	}
 
-	rate, ok := c.rates[amount.CurrencyCode]
-	if !ok {
-		return nil, fmt.Errorf("conversion rate for currency %s not found", amount.CurrencyCode)
+	rate, err := c.rate(amount.CurrencyCode)
+	if err != nil {
+		return nil, err
 	}
-	// invert the rate
-	rate = 1 + (1 - rate)
 
 	// To convert to the base currency, divide by the currency rate.
-	float, err := amount.TimesFloat(rate)
+	converted, err := convertMoney(amount, new(big.Rat).Inv(rate), c.baseCurrency)
 	if err != nil {
 		return nil, fmt.Errorf("error converting to base currency: %w", err)
 	}
-	float.CurrencyCode = c.baseCurrency
 
-	return float, nil
+	return converted, nil
+}
+
+// rate returns the exact rate of a currency, the amount of it one unit of the base currency buys
+func (c *converter) rate(currency string) (*big.Rat, error) {
+	if currency == c.baseCurrency {
+		return big.NewRat(1, 1), nil
+	}
+
+	c.mu.RLock()
+	rate, ok := c.rates[currency]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("conversion rate for currency %s not found", currency)
+	}
+
+	return rateRat(currency, rate)
+}
+
+// rateRat returns the decimal a float rate is written with as an exact fraction,
+// 0.85 is 85/100 and not the binary value of the float closest to it
+func rateRat(currency string, rate float64) (*big.Rat, error) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return nil, fmt.Errorf("conversion rate %s must be a number greater than 0: %f", currency, rate)
+	}
+
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'g', -1, 64))
+	if !ok {
+		return nil, fmt.Errorf("invalid conversion rate %s: %f", currency, rate)
+	}
+
+	return r, nil
+}
+
+// convertMoney multiplies amount by factor into the currency to, rounded once to nanos, half away from zero
+func convertMoney(amount Money, factor *big.Rat, to string) (*Money, error) {
+	nanos, err := amount.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(big.Rat).SetInt(nanos)
+	total.Mul(total, factor)
+
+	return moneyFromNanos(to, roundHalfUp(total))
 }
@@ -1,7 +1,12 @@
 package aicost
 
 import (
+	"math"
+	"math/big"
+	"sync"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -96,9 +101,10 @@ func Test_Converter_Convert(t *testing.T) {
 				CurrencyCode: "EUR",
 			},
 			toCurrency: "USD",
+			// 100 / 0.85
 			want: &Money{
-				Units:        115,
-				Nanos:        0,
+				Units:        117,
+				Nanos:        647058824,
 				CurrencyCode: "USD",
 			},
 			wantErr: false,
@@ -111,9 +117,10 @@ func Test_Converter_Convert(t *testing.T) {
 				CurrencyCode: "EUR",
 			},
 			toCurrency: "GBP",
+			// 100 / 0.85 * 0.75, rounded once
 			want: &Money{
-				Units:        86,
-				Nanos:        250000000,
+				Units:        88,
+				Nanos:        235294118,
 				CurrencyCode: "GBP",
 			},
 			wantErr: false,
@@ -129,6 +136,28 @@ func Test_Converter_Convert(t *testing.T) {
 			want:       nil,
 			wantErr:    true,
 		},
+		{
+			name: "unknown source currency",
+			amount: Money{
+				Units:        100,
+				Nanos:        0,
+				CurrencyCode: "XYZ",
+			},
+			toCurrency: "USD",
+			want:       nil,
+			wantErr:    true,
+		},
+		{
+			name: "unknown target currency",
+			amount: Money{
+				Units:        100,
+				Nanos:        0,
+				CurrencyCode: "USD",
+			},
+			toCurrency: "XYZ",
+			want:       nil,
+			wantErr:    true,
+		},
 		{
 			name: "target currency not found",
 			amount: Money{
@@ -188,8 +217,8 @@ func Test_Converter_ConvertToBase(t *testing.T) {
 				CurrencyCode: "EUR",
 			},
 			want: &Money{
-				Units:        115,
-				Nanos:        0,
+				Units:        117,
+				Nanos:        647058824,
 				CurrencyCode: "USD",
 			},
 			wantErr: false,
@@ -202,8 +231,8 @@ func Test_Converter_ConvertToBase(t *testing.T) {
 				CurrencyCode: "GBP",
 			},
 			want: &Money{
-				Units:        125,
-				Nanos:        0,
+				Units:        133,
+				Nanos:        333333333,
 				CurrencyCode: "USD",
 			},
 			wantErr: false,
@@ -233,3 +262,207 @@ func Test_Converter_ConvertToBase(t *testing.T) {
 		})
 	}
 }
+
+func Test_Converter_Rates(t *testing.T) {
+	tests := []struct {
+		name    string
+		rates   map[string]float64
+		wantErr bool
+		errIs   error
+	}{
+		{name: "valid rates", rates: map[string]float64{"EUR": 0.85, "JPY": 150}},
+		{name: "empty rates", rates: map[string]float64{}, wantErr: true},
+		{name: "zero rate", rates: map[string]float64{"EUR": 0}, wantErr: true},
+		{name: "NaN rate", rates: map[string]float64{"EUR": math.NaN()}, wantErr: true},
+		{name: "infinite rate", rates: map[string]float64{"EUR": math.Inf(1)}, wantErr: true},
+		{name: "unknown currency", rates: map[string]float64{"XYZ": 1.5}, wantErr: true, errIs: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("USD", nil)
+			err := c.Rates(tt.rates)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Nil(t, c.rates)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rates, c.rates)
+		})
+	}
+}
+
+func Test_Converter_Convert_RatesChange(t *testing.T) {
+	usd := Money{Units: 100, CurrencyCode: "USD"}
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	c := NewConverter("USD", testRates)
+	assert.NoError(t, c.RatesAt(day, map[string]float64{"EUR": 0.9}))
+
+	convert := func(at *time.Time) int64 {
+		t.Helper()
+		var got *Money
+		var err error
+		if at == nil {
+			got, err = c.Convert(usd, "EUR")
+		} else {
+			got, err = c.ConvertAt(usd, "EUR", *at)
+		}
+		assert.NoError(t, err)
+		return got.Units
+	}
+
+	assert.Equal(t, int64(85), convert(nil))
+	assert.Equal(t, int64(90), convert(&day))
+
+	// the rates are used from the next conversion on
+	assert.NoError(t, c.Rates(map[string]float64{"EUR": 0.8}))
+	assert.Equal(t, int64(80), convert(nil))
+	assert.NoError(t, c.RatesAt(day, map[string]float64{"EUR": 0.7}))
+	assert.Equal(t, int64(70), convert(&day))
+
+	// and so are the pairs, with the current and the dated rates
+	assert.NoError(t, c.PairRates([]PairRate{{From: "USD", To: "EUR", Rate: 0.6}}))
+	assert.Equal(t, int64(60), convert(nil))
+	assert.Equal(t, int64(60), convert(&day))
+	assert.NoError(t, c.PairRates(nil))
+	assert.Equal(t, int64(80), convert(nil))
+	assert.Equal(t, int64(70), convert(&day))
+
+	// the converter keeps a copy of the rates
+	rates := map[string]float64{"EUR": 0.5}
+	assert.NoError(t, c.Rates(rates))
+	rates["EUR"] = 0.4
+	assert.Equal(t, int64(50), convert(nil))
+}
+
+func Test_Converter_Concurrent(t *testing.T) {
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	usd := Money{Units: 100, CurrencyCode: "USD"}
+	c := NewConverter("USD", testRates)
+	assert.NoError(t, c.RatesAt(day, testRates))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, _ = c.Convert(usd, "EUR")
+				// the fourth day has no rates and falls back
+				_, _ = c.ConvertAt(usd, "GBP", day.AddDate(0, 0, j%4))
+				_, _ = c.convertToBase(Money{Units: 100, CurrencyCode: "JPY"})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, c.Rates(map[string]float64{"EUR": 0.85, "GBP": 0.75}))
+				assert.NoError(t, c.RatesAt(day.AddDate(0, 0, j%3), map[string]float64{"EUR": 0.9, "GBP": 0.8}))
+				assert.NoError(t, c.PairRates([]PairRate{{From: "EUR", To: "GBP", Rate: 0.87}}))
+				assert.NoError(t, c.Fallback(RateFallback(j%2)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := c.Convert(usd, "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 85, CurrencyCode: "EUR"}, got)
+}
+
+func Test_Converter_Convert_FarFromOne(t *testing.T) {
+	testConverter := NewConverter("USD", map[string]float64{
+		"JPY": 150,
+		"KWD": 0.307,
+		"IDR": 16250.5,
+	})
+
+	tests := []struct {
+		name       string
+		amount     Money
+		toCurrency string
+		want       *Money
+	}{
+		{
+			name:       "JPY to base",
+			amount:     Money{Units: 100, CurrencyCode: "JPY"},
+			toCurrency: "USD",
+			// 100 / 150
+			want: &Money{Units: 0, Nanos: 666666667, CurrencyCode: "USD"},
+		},
+		{
+			name:       "base to JPY",
+			amount:     Money{Units: 1, Nanos: 500000000, CurrencyCode: "USD"},
+			toCurrency: "JPY",
+			want:       &Money{Units: 225, CurrencyCode: "JPY"},
+		},
+		{
+			name:       "KWD to JPY",
+			amount:     Money{Units: 1, CurrencyCode: "KWD"},
+			toCurrency: "JPY",
+			// 150 / 0.307
+			want: &Money{Units: 488, Nanos: 599348534, CurrencyCode: "JPY"},
+		},
+		{
+			name:       "negative IDR to KWD",
+			amount:     Money{Units: -16250, Nanos: -500000000, CurrencyCode: "IDR"},
+			toCurrency: "KWD",
+			want:       &Money{Units: 0, Nanos: -307000000, CurrencyCode: "KWD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testConverter.Convert(tt.amount, tt.toCurrency)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Test_Converter_Convert_RoundTrip checks Convert(Convert(x, B), A) stays within the round-trip bound documented on Convert,
+// half a nano of B worth rate(A)/rate(B) nanos of A, plus half a nano of A
+func Test_Converter_Convert_RoundTrip(t *testing.T) {
+	rates := map[string]float64{
+		"EUR": 0.85,
+		"JPY": 150,
+		"KWD": 0.307,
+		"IDR": 16250.5,
+	}
+	testConverter := NewConverter("USD", rates)
+	codes := []string{"USD", "EUR", "JPY", "KWD", "IDR"}
+
+	roundTrip := func(units int64, nanos int32, from, to uint8) bool {
+		a, b := codes[int(from)%len(codes)], codes[int(to)%len(codes)]
+		units %= 1_000_000_000_000
+		nanos %= 1_000_000_000
+		if (units < 0 && nanos > 0) || (units > 0 && nanos < 0) {
+			nanos = -nanos
+		}
+		x := Money{Units: units, Nanos: nanos, CurrencyCode: a}
+
+		there, err := testConverter.Convert(x, b)
+		if err != nil {
+			return false
+		}
+		back, err := testConverter.Convert(*there, a)
+		if err != nil {
+			return false
+		}
+
+		rateA, _ := testConverter.rate(a)
+		rateB, _ := testConverter.rate(b)
+		tolerance := new(big.Rat).Quo(rateA, rateB)
+		tolerance.Add(tolerance, big.NewRat(1, 1))
+		tolerance.Quo(tolerance, big.NewRat(2, 1))
+
+		diff := new(big.Rat).SetInt(new(big.Int).Sub(back.totalNanos(), x.totalNanos()))
+		return diff.Abs(diff).Cmp(tolerance) <= 0
+	}
+
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 5000}))
+}
@@ -0,0 +1,224 @@
+package aicost
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// PairRate is a direct quote between two currencies, one unit of From buys Rate units of To
+type PairRate struct {
+	From string  `json:"from" yaml:"from"`
+	To   string  `json:"to" yaml:"to"`
+	Rate float64 `json:"rate" yaml:"rate"`
+}
+
+// RateLeg is a step of a conversion path
+type RateLeg struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+	// Rate is the exact rate of the step, one unit of From buys Rate units of To
+	Rate *big.Rat `json:"rate" yaml:"rate"`
+	// Inverted is set when the step uses the inverse of a rate quoted from To to From
+	Inverted bool `json:"inverted,omitempty" yaml:"inverted,omitempty"`
+}
+
+// Conversion is a converted amount with the rates it was converted with
+type Conversion struct {
+	Amount Money  `json:"amount" yaml:"amount"`
+	Result *Money `json:"result" yaml:"result"`
+	// Path holds the currencies of the conversion in order, from the source to the target currency
+	Path []string `json:"path" yaml:"path"`
+	// Legs holds the rate of every step of the path, none when the currencies are the same
+	Legs []RateLeg `json:"legs,omitempty" yaml:"legs,omitempty"`
+	// Rate is the exact effective rate, the product of the rates of the legs
+	Rate *big.Rat `json:"rate" yaml:"rate"`
+	// RatesDate is the date of the base rates used by ConvertPathAt, nil for the current rates
+	RatesDate *time.Time `json:"rates_date,omitempty" yaml:"rates_date,omitempty"`
+}
+
+// PairRates sets the direct quotes between currencies, replacing the previous ones, nil or empty removes them
+// a quote is also used inverted, from To to From, unless that direction is quoted too,
+// and it takes precedence over the base rates of the same two currencies
+func (c *converter) PairRates(pairs []PairRate) error {
+	seen := make(map[[2]string]bool, len(pairs))
+	for _, p := range pairs {
+		if err := validateCurrency(p.From); err != nil {
+			return fmt.Errorf("pair rate %s/%s: %w", p.From, p.To, err)
+		}
+		if err := validateCurrency(p.To); err != nil {
+			return fmt.Errorf("pair rate %s/%s: %w", p.From, p.To, err)
+		}
+		if p.From == p.To {
+			return fmt.Errorf("pair rate %s/%s: currencies must differ", p.From, p.To)
+		}
+		if _, err := rateRat(p.From+"/"+p.To, p.Rate); err != nil {
+			return err
+		}
+
+		key := [2]string{p.From, p.To}
+		if seen[key] {
+			return fmt.Errorf("pair rate %s/%s is quoted twice", p.From, p.To)
+		}
+		seen[key] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the pairs are part of the graph of every date, they are built again on first use
+	c.graphs = nil
+	c.datedGraphs = nil
+	if len(pairs) == 0 {
+		c.pairs = nil
+		return nil
+	}
+	c.pairs = append([]PairRate(nil), pairs...)
+
+	return nil
+}
+
+// rateGraph holds the rate of every currency pair a conversion can step through
+type rateGraph struct {
+	// legs holds the rates by source and target currency
+	legs map[string]map[string]RateLeg
+	// next holds the currencies one step away from every currency, in alphabetical order
+	next map[string][]string
+}
+
+// currentRates is the key of the graph of the current rates, the dated rates are stored under their date
+const currentRates = ""
+
+// maxDatedGraphs is how many graphs of dated rates are kept, the oldest built is dropped first
+const maxDatedGraphs = 64
+
+// graph returns the rate graph of the base rates stored under key and the pair quotes, building it once
+func (c *converter) graph(key string) (*rateGraph, error) {
+	c.mu.RLock()
+	g, ok := c.graphs[key]
+	c.mu.RUnlock()
+	if ok {
+		return g, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.graphLocked(key)
+}
+
+// graphLocked returns the graph stored under key, building and storing it when there is none
+// the caller holds the write lock
+func (c *converter) graphLocked(key string) (*rateGraph, error) {
+	if g, ok := c.graphs[key]; ok {
+		return g, nil
+	}
+
+	rates := c.rates
+	if key != currentRates {
+		rates = c.history[key]
+	}
+	g, err := newRateGraph(c.baseCurrency, rates, c.pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.graphs == nil {
+		c.graphs = map[string]*rateGraph{}
+	}
+	if key != currentRates {
+		if len(c.datedGraphs) >= maxDatedGraphs {
+			delete(c.graphs, c.datedGraphs[0])
+			c.datedGraphs = c.datedGraphs[1:]
+		}
+		c.datedGraphs = append(c.datedGraphs, key)
+	}
+	c.graphs[key] = g
+
+	return g, nil
+}
+
+// newRateGraph returns the graph of the rates of the base currency and the pair quotes, it is not changed once built
+func newRateGraph(baseCurrency string, rates map[string]float64, pairs []PairRate) (*rateGraph, error) {
+	g := &rateGraph{legs: map[string]map[string]RateLeg{}}
+
+	for cur, rate := range rates {
+		if cur == baseCurrency {
+			continue
+		}
+		r, err := rateRat(cur, rate)
+		if err != nil {
+			return nil, err
+		}
+		g.quote(baseCurrency, cur, r)
+	}
+
+	// pairs are added last, a quote replaces the base rate of the same two currencies
+	for _, p := range pairs {
+		r, err := rateRat(p.From+"/"+p.To, p.Rate)
+		if err != nil {
+			return nil, err
+		}
+		g.quote(p.From, p.To, r)
+	}
+
+	g.next = make(map[string][]string, len(g.legs))
+	for from, legs := range g.legs {
+		for to := range legs {
+			g.next[from] = append(g.next[from], to)
+		}
+		sort.Strings(g.next[from])
+	}
+
+	return g, nil
+}
+
+// quote adds a rate from one currency to another and its inverse, the inverse never replaces a quoted rate
+func (g *rateGraph) quote(from, to string, rate *big.Rat) {
+	g.set(RateLeg{From: from, To: to, Rate: rate})
+
+	if leg, ok := g.legs[to][from]; ok && !leg.Inverted {
+		return
+	}
+	g.set(RateLeg{From: to, To: from, Rate: new(big.Rat).Inv(rate), Inverted: true})
+}
+
+func (g *rateGraph) set(leg RateLeg) {
+	if g.legs[leg.From] == nil {
+		g.legs[leg.From] = map[string]RateLeg{}
+	}
+	g.legs[leg.From][leg.To] = leg
+}
+
+// path returns the legs of the path with the fewest steps from one currency to another, nil when there is none
+// paths of the same length are picked by the alphabetical order of their currencies, so the choice is stable
+func (g *rateGraph) path(from, to string) []RateLeg {
+	previous := map[string]RateLeg{from: {}}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur == to {
+			var legs []RateLeg
+			for cur != from {
+				leg := previous[cur]
+				legs = append([]RateLeg{leg}, legs...)
+				cur = leg.From
+			}
+			return legs
+		}
+
+		for _, n := range g.next[cur] {
+			if _, ok := previous[n]; ok {
+				continue
+			}
+			previous[n] = g.legs[cur][n]
+			queue = append(queue, n)
+		}
+	}
+
+	return nil
+}
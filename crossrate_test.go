@@ -0,0 +1,203 @@
+package aicost
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Converter_ConvertPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		rates      map[string]float64
+		pairs      []PairRate
+		amount     Money
+		toCurrency string
+		want       *Money
+		wantPath   []string
+		wantRate   *big.Rat
+		wantLegs   []RateLeg
+		wantErr    bool
+	}{
+		{
+			name:       "same currency",
+			rates:      testRates,
+			amount:     Money{Units: 100, CurrencyCode: "EUR"},
+			toCurrency: "EUR",
+			want:       &Money{Units: 100, CurrencyCode: "EUR"},
+			wantPath:   []string{"EUR"},
+			wantRate:   big.NewRat(1, 1),
+		},
+		{
+			name:       "cross rate through the base currency",
+			rates:      testRates,
+			amount:     Money{Units: 100, CurrencyCode: "EUR"},
+			toCurrency: "GBP",
+			want:       &Money{Units: 88, Nanos: 235294118, CurrencyCode: "GBP"},
+			wantPath:   []string{"EUR", "USD", "GBP"},
+			wantRate:   big.NewRat(15, 17),
+			wantLegs: []RateLeg{
+				{From: "EUR", To: "USD", Rate: big.NewRat(20, 17), Inverted: true},
+				{From: "USD", To: "GBP", Rate: big.NewRat(3, 4)},
+			},
+		},
+		{
+			name:       "direct pair",
+			rates:      testRates,
+			pairs:      []PairRate{{From: "EUR", To: "GBP", Rate: 0.87}},
+			amount:     Money{Units: 100, CurrencyCode: "EUR"},
+			toCurrency: "GBP",
+			want:       &Money{Units: 87, CurrencyCode: "GBP"},
+			wantPath:   []string{"EUR", "GBP"},
+			wantRate:   big.NewRat(87, 100),
+			wantLegs:   []RateLeg{{From: "EUR", To: "GBP", Rate: big.NewRat(87, 100)}},
+		},
+		{
+			name:       "inverted pair",
+			rates:      testRates,
+			pairs:      []PairRate{{From: "EUR", To: "GBP", Rate: 0.87}},
+			amount:     Money{Units: 100, CurrencyCode: "GBP"},
+			toCurrency: "EUR",
+			// 100 / 0.87
+			want:     &Money{Units: 114, Nanos: 942528736, CurrencyCode: "EUR"},
+			wantPath: []string{"GBP", "EUR"},
+			wantRate: big.NewRat(100, 87),
+			wantLegs: []RateLeg{{From: "GBP", To: "EUR", Rate: big.NewRat(100, 87), Inverted: true}},
+		},
+		{
+			name:  "both directions quoted",
+			rates: testRates,
+			pairs: []PairRate{
+				{From: "EUR", To: "GBP", Rate: 0.87},
+				{From: "GBP", To: "EUR", Rate: 1.14},
+			},
+			amount:     Money{Units: 100, CurrencyCode: "GBP"},
+			toCurrency: "EUR",
+			want:       &Money{Units: 114, CurrencyCode: "EUR"},
+			wantPath:   []string{"GBP", "EUR"},
+			wantRate:   big.NewRat(114, 100),
+			wantLegs:   []RateLeg{{From: "GBP", To: "EUR", Rate: big.NewRat(114, 100)}},
+		},
+		{
+			name:       "pair replaces the base rate",
+			rates:      testRates,
+			pairs:      []PairRate{{From: "USD", To: "EUR", Rate: 0.86}},
+			amount:     Money{Units: 100, CurrencyCode: "USD"},
+			toCurrency: "EUR",
+			want:       &Money{Units: 86, CurrencyCode: "EUR"},
+			wantPath:   []string{"USD", "EUR"},
+			wantRate:   big.NewRat(86, 100),
+			wantLegs:   []RateLeg{{From: "USD", To: "EUR", Rate: big.NewRat(86, 100)}},
+		},
+		{
+			name:  "triangulation through pairs only",
+			rates: nil,
+			pairs: []PairRate{
+				{From: "EUR", To: "GBP", Rate: 0.87},
+				{From: "GBP", To: "JPY", Rate: 190},
+			},
+			amount:     Money{Units: 100, CurrencyCode: "EUR"},
+			toCurrency: "JPY",
+			want:       &Money{Units: 16530, CurrencyCode: "JPY"},
+			wantPath:   []string{"EUR", "GBP", "JPY"},
+			wantRate:   big.NewRat(16530, 100),
+			wantLegs: []RateLeg{
+				{From: "EUR", To: "GBP", Rate: big.NewRat(87, 100)},
+				{From: "GBP", To: "JPY", Rate: big.NewRat(190, 1)},
+			},
+		},
+		{
+			name:       "base rate then pair",
+			rates:      map[string]float64{"EUR": 0.85},
+			pairs:      []PairRate{{From: "EUR", To: "CHF", Rate: 0.94}},
+			amount:     Money{Units: 100, CurrencyCode: "USD"},
+			toCurrency: "CHF",
+			want:       &Money{Units: 79, Nanos: 900000000, CurrencyCode: "CHF"},
+			wantPath:   []string{"USD", "EUR", "CHF"},
+			wantRate:   big.NewRat(799, 1000),
+			wantLegs: []RateLeg{
+				{From: "USD", To: "EUR", Rate: big.NewRat(85, 100)},
+				{From: "EUR", To: "CHF", Rate: big.NewRat(94, 100)},
+			},
+		},
+		{
+			name:       "no path",
+			rates:      nil,
+			pairs:      []PairRate{{From: "EUR", To: "GBP", Rate: 0.87}},
+			amount:     Money{Units: 100, CurrencyCode: "EUR"},
+			toCurrency: "JPY",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("USD", tt.rates)
+			assert.NoError(t, c.PairRates(tt.pairs))
+
+			got, err := c.ConvertPath(tt.amount, tt.toCurrency)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.amount, got.Amount)
+			assert.Equal(t, tt.want, got.Result)
+			assert.Equal(t, tt.wantPath, got.Path)
+			assert.Equal(t, 0, tt.wantRate.Cmp(got.Rate), "rate %s", got.Rate)
+			assert.Len(t, got.Legs, len(tt.wantLegs))
+			for i, leg := range tt.wantLegs {
+				assert.Equal(t, leg.From, got.Legs[i].From)
+				assert.Equal(t, leg.To, got.Legs[i].To)
+				assert.Equal(t, leg.Inverted, got.Legs[i].Inverted)
+				assert.Equal(t, 0, leg.Rate.Cmp(got.Legs[i].Rate), "leg %d rate %s", i, got.Legs[i].Rate)
+			}
+
+			converted, err := c.Convert(tt.amount, tt.toCurrency)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, converted)
+		})
+	}
+}
+
+func Test_Converter_PairRates(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []PairRate
+		wantErr bool
+		errIs   error
+	}{
+		{name: "valid pairs", pairs: []PairRate{{From: "EUR", To: "GBP", Rate: 0.87}, {From: "GBP", To: "EUR", Rate: 1.14}}},
+		{name: "nil removes the pairs", pairs: nil},
+		{name: "same currency", pairs: []PairRate{{From: "EUR", To: "EUR", Rate: 1}}, wantErr: true},
+		{name: "zero rate", pairs: []PairRate{{From: "EUR", To: "GBP", Rate: 0}}, wantErr: true},
+		{name: "unknown currency", pairs: []PairRate{{From: "EUR", To: "XYZ", Rate: 2}}, wantErr: true, errIs: ErrUnknownCurrency},
+		{name: "quoted twice", pairs: []PairRate{{From: "EUR", To: "GBP", Rate: 0.87}, {From: "EUR", To: "GBP", Rate: 0.88}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("USD", testRates)
+			previous := []PairRate{{From: "USD", To: "CHF", Rate: 0.8}}
+			assert.NoError(t, c.PairRates(previous))
+
+			err := c.PairRates(tt.pairs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Equal(t, previous, c.pairs)
+				return
+			}
+			assert.NoError(t, err)
+			if len(tt.pairs) == 0 {
+				assert.Nil(t, c.pairs)
+				return
+			}
+			assert.Equal(t, tt.pairs, c.pairs)
+		})
+	}
+}
@@ -1,8 +1,283 @@
 package aicost
 
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
 // ISO 4217 currency codes
 
 const (
 	CurrencyUSD = "USD"
 	CurrencyEUR = "EUR"
 )
+
+var ErrUnknownCurrency = errors.New("unknown currency code")
+
+// Currency describes a currency of the registry
+type Currency struct {
+	// Code is the ISO 4217 alphabetic code, or the code of a custom unit
+	Code string
+	// Numeric is the ISO 4217 numeric code, 0 for custom units
+	Numeric int
+	// MinorUnits is the number of decimal places of the currency, from 0 to 9
+	MinorUnits int
+	// Symbol is the usual sign of the currency, empty when the code is used instead
+	Symbol string
+	// Name is the English name of the currency
+	Name string
+}
+
+// iso4217 lists the active ISO 4217 currencies, without precious metals and codes without minor units
+var iso4217 = []Currency{
+	{Code: "AED", Numeric: 784, MinorUnits: 2, Symbol: "د.إ", Name: "UAE Dirham"},
+	{Code: "AFN", Numeric: 971, MinorUnits: 2, Symbol: "؋", Name: "Afghani"},
+	{Code: "ALL", Numeric: 8, MinorUnits: 2, Symbol: "", Name: "Lek"},
+	{Code: "AMD", Numeric: 51, MinorUnits: 2, Symbol: "֏", Name: "Armenian Dram"},
+	{Code: "ANG", Numeric: 532, MinorUnits: 2, Symbol: "", Name: "Netherlands Antillean Guilder"},
+	{Code: "AOA", Numeric: 973, MinorUnits: 2, Symbol: "", Name: "Kwanza"},
+	{Code: "ARS", Numeric: 32, MinorUnits: 2, Symbol: "", Name: "Argentine Peso"},
+	{Code: "AUD", Numeric: 36, MinorUnits: 2, Symbol: "A$", Name: "Australian Dollar"},
+	{Code: "AWG", Numeric: 533, MinorUnits: 2, Symbol: "", Name: "Aruban Florin"},
+	{Code: "AZN", Numeric: 944, MinorUnits: 2, Symbol: "₼", Name: "Azerbaijan Manat"},
+	{Code: "BAM", Numeric: 977, MinorUnits: 2, Symbol: "", Name: "Convertible Mark"},
+	{Code: "BBD", Numeric: 52, MinorUnits: 2, Symbol: "", Name: "Barbados Dollar"},
+	{Code: "BDT", Numeric: 50, MinorUnits: 2, Symbol: "৳", Name: "Taka"},
+	{Code: "BGN", Numeric: 975, MinorUnits: 2, Symbol: "", Name: "Bulgarian Lev"},
+	{Code: "BHD", Numeric: 48, MinorUnits: 3, Symbol: "", Name: "Bahraini Dinar"},
+	{Code: "BIF", Numeric: 108, MinorUnits: 0, Symbol: "", Name: "Burundi Franc"},
+	{Code: "BMD", Numeric: 60, MinorUnits: 2, Symbol: "", Name: "Bermudian Dollar"},
+	{Code: "BND", Numeric: 96, MinorUnits: 2, Symbol: "", Name: "Brunei Dollar"},
+	{Code: "BOB", Numeric: 68, MinorUnits: 2, Symbol: "", Name: "Boliviano"},
+	{Code: "BOV", Numeric: 984, MinorUnits: 2, Symbol: "", Name: "Mvdol"},
+	{Code: "BRL", Numeric: 986, MinorUnits: 2, Symbol: "R$", Name: "Brazilian Real"},
+	{Code: "BSD", Numeric: 44, MinorUnits: 2, Symbol: "", Name: "Bahamian Dollar"},
+	{Code: "BTN", Numeric: 64, MinorUnits: 2, Symbol: "", Name: "Ngultrum"},
+	{Code: "BWP", Numeric: 72, MinorUnits: 2, Symbol: "", Name: "Pula"},
+	{Code: "BYN", Numeric: 933, MinorUnits: 2, Symbol: "", Name: "Belarusian Ruble"},
+	{Code: "BZD", Numeric: 84, MinorUnits: 2, Symbol: "", Name: "Belize Dollar"},
+	{Code: "CAD", Numeric: 124, MinorUnits: 2, Symbol: "CA$", Name: "Canadian Dollar"},
+	{Code: "CDF", Numeric: 976, MinorUnits: 2, Symbol: "", Name: "Congolese Franc"},
+	{Code: "CHE", Numeric: 947, MinorUnits: 2, Symbol: "", Name: "WIR Euro"},
+	{Code: "CHF", Numeric: 756, MinorUnits: 2, Symbol: "CHF", Name: "Swiss Franc"},
+	{Code: "CHW", Numeric: 948, MinorUnits: 2, Symbol: "", Name: "WIR Franc"},
+	{Code: "CLF", Numeric: 990, MinorUnits: 4, Symbol: "", Name: "Unidad de Fomento"},
+	{Code: "CLP", Numeric: 152, MinorUnits: 0, Symbol: "", Name: "Chilean Peso"},
+	{Code: "CNY", Numeric: 156, MinorUnits: 2, Symbol: "CN¥", Name: "Yuan Renminbi"},
+	{Code: "COP", Numeric: 170, MinorUnits: 2, Symbol: "", Name: "Colombian Peso"},
+	{Code: "COU", Numeric: 970, MinorUnits: 2, Symbol: "", Name: "Unidad de Valor Real"},
+	{Code: "CRC", Numeric: 188, MinorUnits: 2, Symbol: "₡", Name: "Costa Rican Colon"},
+	{Code: "CUP", Numeric: 192, MinorUnits: 2, Symbol: "", Name: "Cuban Peso"},
+	{Code: "CVE", Numeric: 132, MinorUnits: 2, Symbol: "", Name: "Cabo Verde Escudo"},
+	{Code: "CZK", Numeric: 203, MinorUnits: 2, Symbol: "Kč", Name: "Czech Koruna"},
+	{Code: "DJF", Numeric: 262, MinorUnits: 0, Symbol: "", Name: "Djibouti Franc"},
+	{Code: "DKK", Numeric: 208, MinorUnits: 2, Symbol: "kr", Name: "Danish Krone"},
+	{Code: "DOP", Numeric: 214, MinorUnits: 2, Symbol: "", Name: "Dominican Peso"},
+	{Code: "DZD", Numeric: 12, MinorUnits: 2, Symbol: "", Name: "Algerian Dinar"},
+	{Code: "EGP", Numeric: 818, MinorUnits: 2, Symbol: "E£", Name: "Egyptian Pound"},
+	{Code: "ERN", Numeric: 232, MinorUnits: 2, Symbol: "", Name: "Nakfa"},
+	{Code: "ETB", Numeric: 230, MinorUnits: 2, Symbol: "", Name: "Ethiopian Birr"},
+	{Code: "EUR", Numeric: 978, MinorUnits: 2, Symbol: "€", Name: "Euro"},
+	{Code: "FJD", Numeric: 242, MinorUnits: 2, Symbol: "", Name: "Fiji Dollar"},
+	{Code: "FKP", Numeric: 238, MinorUnits: 2, Symbol: "", Name: "Falkland Islands Pound"},
+	{Code: "GBP", Numeric: 826, MinorUnits: 2, Symbol: "£", Name: "Pound Sterling"},
+	{Code: "GEL", Numeric: 981, MinorUnits: 2, Symbol: "₾", Name: "Lari"},
+	{Code: "GHS", Numeric: 936, MinorUnits: 2, Symbol: "₵", Name: "Ghana Cedi"},
+	{Code: "GIP", Numeric: 292, MinorUnits: 2, Symbol: "", Name: "Gibraltar Pound"},
+	{Code: "GMD", Numeric: 270, MinorUnits: 2, Symbol: "", Name: "Dalasi"},
+	{Code: "GNF", Numeric: 324, MinorUnits: 0, Symbol: "", Name: "Guinean Franc"},
+	{Code: "GTQ", Numeric: 320, MinorUnits: 2, Symbol: "", Name: "Quetzal"},
+	{Code: "GYD", Numeric: 328, MinorUnits: 2, Symbol: "", Name: "Guyana Dollar"},
+	{Code: "HKD", Numeric: 344, MinorUnits: 2, Symbol: "HK$", Name: "Hong Kong Dollar"},
+	{Code: "HNL", Numeric: 340, MinorUnits: 2, Symbol: "", Name: "Lempira"},
+	{Code: "HTG", Numeric: 332, MinorUnits: 2, Symbol: "", Name: "Gourde"},
+	{Code: "HUF", Numeric: 348, MinorUnits: 2, Symbol: "Ft", Name: "Forint"},
+	{Code: "IDR", Numeric: 360, MinorUnits: 2, Symbol: "Rp", Name: "Rupiah"},
+	{Code: "ILS", Numeric: 376, MinorUnits: 2, Symbol: "₪", Name: "New Israeli Sheqel"},
+	{Code: "INR", Numeric: 356, MinorUnits: 2, Symbol: "₹", Name: "Indian Rupee"},
+	{Code: "IQD", Numeric: 368, MinorUnits: 3, Symbol: "", Name: "Iraqi Dinar"},
+	{Code: "IRR", Numeric: 364, MinorUnits: 2, Symbol: "", Name: "Iranian Rial"},
+	{Code: "ISK", Numeric: 352, MinorUnits: 0, Symbol: "", Name: "Iceland Krona"},
+	{Code: "JMD", Numeric: 388, MinorUnits: 2, Symbol: "", Name: "Jamaican Dollar"},
+	{Code: "JOD", Numeric: 400, MinorUnits: 3, Symbol: "", Name: "Jordanian Dinar"},
+	{Code: "JPY", Numeric: 392, MinorUnits: 0, Symbol: "¥", Name: "Yen"},
+	{Code: "KES", Numeric: 404, MinorUnits: 2, Symbol: "", Name: "Kenyan Shilling"},
+	{Code: "KGS", Numeric: 417, MinorUnits: 2, Symbol: "", Name: "Som"},
+	{Code: "KHR", Numeric: 116, MinorUnits: 2, Symbol: "", Name: "Riel"},
+	{Code: "KMF", Numeric: 174, MinorUnits: 0, Symbol: "", Name: "Comorian Franc"},
+	{Code: "KPW", Numeric: 408, MinorUnits: 2, Symbol: "", Name: "North Korean Won"},
+	{Code: "KRW", Numeric: 410, MinorUnits: 0, Symbol: "₩", Name: "Won"},
+	{Code: "KWD", Numeric: 414, MinorUnits: 3, Symbol: "", Name: "Kuwaiti Dinar"},
+	{Code: "KYD", Numeric: 136, MinorUnits: 2, Symbol: "", Name: "Cayman Islands Dollar"},
+	{Code: "KZT", Numeric: 398, MinorUnits: 2, Symbol: "₸", Name: "Tenge"},
+	{Code: "LAK", Numeric: 418, MinorUnits: 2, Symbol: "₭", Name: "Lao Kip"},
+	{Code: "LBP", Numeric: 422, MinorUnits: 2, Symbol: "", Name: "Lebanese Pound"},
+	{Code: "LKR", Numeric: 144, MinorUnits: 2, Symbol: "", Name: "Sri Lanka Rupee"},
+	{Code: "LRD", Numeric: 430, MinorUnits: 2, Symbol: "", Name: "Liberian Dollar"},
+	{Code: "LSL", Numeric: 426, MinorUnits: 2, Symbol: "", Name: "Loti"},
+	{Code: "LYD", Numeric: 434, MinorUnits: 3, Symbol: "", Name: "Libyan Dinar"},
+	{Code: "MAD", Numeric: 504, MinorUnits: 2, Symbol: "", Name: "Moroccan Dirham"},
+	{Code: "MDL", Numeric: 498, MinorUnits: 2, Symbol: "", Name: "Moldovan Leu"},
+	{Code: "MGA", Numeric: 969, MinorUnits: 2, Symbol: "", Name: "Malagasy Ariary"},
+	{Code: "MKD", Numeric: 807, MinorUnits: 2, Symbol: "", Name: "Denar"},
+	{Code: "MMK", Numeric: 104, MinorUnits: 2, Symbol: "", Name: "Kyat"},
+	{Code: "MNT", Numeric: 496, MinorUnits: 2, Symbol: "₮", Name: "Tugrik"},
+	{Code: "MOP", Numeric: 446, MinorUnits: 2, Symbol: "", Name: "Pataca"},
+	{Code: "MRU", Numeric: 929, MinorUnits: 2, Symbol: "", Name: "Ouguiya"},
+	{Code: "MUR", Numeric: 480, MinorUnits: 2, Symbol: "", Name: "Mauritius Rupee"},
+	{Code: "MVR", Numeric: 462, MinorUnits: 2, Symbol: "", Name: "Rufiyaa"},
+	{Code: "MWK", Numeric: 454, MinorUnits: 2, Symbol: "", Name: "Malawi Kwacha"},
+	{Code: "MXN", Numeric: 484, MinorUnits: 2, Symbol: "MX$", Name: "Mexican Peso"},
+	{Code: "MXV", Numeric: 979, MinorUnits: 2, Symbol: "", Name: "Mexican Unidad de Inversion"},
+	{Code: "MYR", Numeric: 458, MinorUnits: 2, Symbol: "RM", Name: "Malaysian Ringgit"},
+	{Code: "MZN", Numeric: 943, MinorUnits: 2, Symbol: "", Name: "Mozambique Metical"},
+	{Code: "NAD", Numeric: 516, MinorUnits: 2, Symbol: "", Name: "Namibia Dollar"},
+	{Code: "NGN", Numeric: 566, MinorUnits: 2, Symbol: "₦", Name: "Naira"},
+	{Code: "NIO", Numeric: 558, MinorUnits: 2, Symbol: "", Name: "Cordoba Oro"},
+	{Code: "NOK", Numeric: 578, MinorUnits: 2, Symbol: "kr", Name: "Norwegian Krone"},
+	{Code: "NPR", Numeric: 524, MinorUnits: 2, Symbol: "", Name: "Nepalese Rupee"},
+	{Code: "NZD", Numeric: 554, MinorUnits: 2, Symbol: "NZ$", Name: "New Zealand Dollar"},
+	{Code: "OMR", Numeric: 512, MinorUnits: 3, Symbol: "", Name: "Rial Omani"},
+	{Code: "PAB", Numeric: 590, MinorUnits: 2, Symbol: "", Name: "Balboa"},
+	{Code: "PEN", Numeric: 604, MinorUnits: 2, Symbol: "", Name: "Sol"},
+	{Code: "PGK", Numeric: 598, MinorUnits: 2, Symbol: "", Name: "Kina"},
+	{Code: "PHP", Numeric: 608, MinorUnits: 2, Symbol: "₱", Name: "Philippine Peso"},
+	{Code: "PKR", Numeric: 586, MinorUnits: 2, Symbol: "", Name: "Pakistan Rupee"},
+	{Code: "PLN", Numeric: 985, MinorUnits: 2, Symbol: "zł", Name: "Zloty"},
+	{Code: "PYG", Numeric: 600, MinorUnits: 0, Symbol: "₲", Name: "Guarani"},
+	{Code: "QAR", Numeric: 634, MinorUnits: 2, Symbol: "", Name: "Qatari Rial"},
+	{Code: "RON", Numeric: 946, MinorUnits: 2, Symbol: "", Name: "Romanian Leu"},
+	{Code: "RSD", Numeric: 941, MinorUnits: 2, Symbol: "", Name: "Serbian Dinar"},
+	{Code: "RUB", Numeric: 643, MinorUnits: 2, Symbol: "₽", Name: "Russian Ruble"},
+	{Code: "RWF", Numeric: 646, MinorUnits: 0, Symbol: "", Name: "Rwanda Franc"},
+	{Code: "SAR", Numeric: 682, MinorUnits: 2, Symbol: "", Name: "Saudi Riyal"},
+	{Code: "SBD", Numeric: 90, MinorUnits: 2, Symbol: "", Name: "Solomon Islands Dollar"},
+	{Code: "SCR", Numeric: 690, MinorUnits: 2, Symbol: "", Name: "Seychelles Rupee"},
+	{Code: "SDG", Numeric: 938, MinorUnits: 2, Symbol: "", Name: "Sudanese Pound"},
+	{Code: "SEK", Numeric: 752, MinorUnits: 2, Symbol: "kr", Name: "Swedish Krona"},
+	{Code: "SGD", Numeric: 702, MinorUnits: 2, Symbol: "S$", Name: "Singapore Dollar"},
+	{Code: "SHP", Numeric: 654, MinorUnits: 2, Symbol: "", Name: "Saint Helena Pound"},
+	{Code: "SLE", Numeric: 925, MinorUnits: 2, Symbol: "", Name: "Leone"},
+	{Code: "SOS", Numeric: 706, MinorUnits: 2, Symbol: "", Name: "Somali Shilling"},
+	{Code: "SRD", Numeric: 968, MinorUnits: 2, Symbol: "", Name: "Surinam Dollar"},
+	{Code: "SSP", Numeric: 728, MinorUnits: 2, Symbol: "", Name: "South Sudanese Pound"},
+	{Code: "STN", Numeric: 930, MinorUnits: 2, Symbol: "", Name: "Dobra"},
+	{Code: "SVC", Numeric: 222, MinorUnits: 2, Symbol: "", Name: "El Salvador Colon"},
+	{Code: "SYP", Numeric: 760, MinorUnits: 2, Symbol: "", Name: "Syrian Pound"},
+	{Code: "SZL", Numeric: 748, MinorUnits: 2, Symbol: "", Name: "Lilangeni"},
+	{Code: "THB", Numeric: 764, MinorUnits: 2, Symbol: "฿", Name: "Baht"},
+	{Code: "TJS", Numeric: 972, MinorUnits: 2, Symbol: "", Name: "Somoni"},
+	{Code: "TMT", Numeric: 934, MinorUnits: 2, Symbol: "", Name: "Turkmenistan New Manat"},
+	{Code: "TND", Numeric: 788, MinorUnits: 3, Symbol: "", Name: "Tunisian Dinar"},
+	{Code: "TOP", Numeric: 776, MinorUnits: 2, Symbol: "", Name: "Pa'anga"},
+	{Code: "TRY", Numeric: 949, MinorUnits: 2, Symbol: "₺", Name: "Turkish Lira"},
+	{Code: "TTD", Numeric: 780, MinorUnits: 2, Symbol: "", Name: "Trinidad and Tobago Dollar"},
+	{Code: "TWD", Numeric: 901, MinorUnits: 2, Symbol: "NT$", Name: "New Taiwan Dollar"},
+	{Code: "TZS", Numeric: 834, MinorUnits: 2, Symbol: "", Name: "Tanzanian Shilling"},
+	{Code: "UAH", Numeric: 980, MinorUnits: 2, Symbol: "₴", Name: "Hryvnia"},
+	{Code: "UGX", Numeric: 800, MinorUnits: 0, Symbol: "", Name: "Uganda Shilling"},
+	{Code: "USD", Numeric: 840, MinorUnits: 2, Symbol: "$", Name: "US Dollar"},
+	{Code: "USN", Numeric: 997, MinorUnits: 2, Symbol: "", Name: "US Dollar (Next day)"},
+	{Code: "UYI", Numeric: 940, MinorUnits: 0, Symbol: "", Name: "Uruguay Peso en Unidades Indexadas"},
+	{Code: "UYU", Numeric: 858, MinorUnits: 2, Symbol: "", Name: "Peso Uruguayo"},
+	{Code: "UYW", Numeric: 927, MinorUnits: 4, Symbol: "", Name: "Unidad Previsional"},
+	{Code: "UZS", Numeric: 860, MinorUnits: 2, Symbol: "", Name: "Uzbekistan Sum"},
+	{Code: "VED", Numeric: 926, MinorUnits: 2, Symbol: "", Name: "Bolivar Soberano"},
+	{Code: "VES", Numeric: 928, MinorUnits: 2, Symbol: "", Name: "Bolivar Soberano"},
+	{Code: "VND", Numeric: 704, MinorUnits: 0, Symbol: "₫", Name: "Dong"},
+	{Code: "VUV", Numeric: 548, MinorUnits: 0, Symbol: "", Name: "Vatu"},
+	{Code: "WST", Numeric: 882, MinorUnits: 2, Symbol: "", Name: "Tala"},
+	{Code: "XAF", Numeric: 950, MinorUnits: 0, Symbol: "", Name: "CFA Franc BEAC"},
+	{Code: "XCD", Numeric: 951, MinorUnits: 2, Symbol: "EC$", Name: "East Caribbean Dollar"},
+	{Code: "XCG", Numeric: 532, MinorUnits: 2, Symbol: "", Name: "Caribbean Guilder"},
+	{Code: "XOF", Numeric: 952, MinorUnits: 0, Symbol: "", Name: "CFA Franc BCEAO"},
+	{Code: "XPF", Numeric: 953, MinorUnits: 0, Symbol: "", Name: "CFP Franc"},
+	{Code: "YER", Numeric: 886, MinorUnits: 2, Symbol: "", Name: "Yemeni Rial"},
+	{Code: "ZAR", Numeric: 710, MinorUnits: 2, Symbol: "R", Name: "Rand"},
+	{Code: "ZMW", Numeric: 967, MinorUnits: 2, Symbol: "", Name: "Zambian Kwacha"},
+	{Code: "ZWG", Numeric: 924, MinorUnits: 2, Symbol: "", Name: "Zimbabwe Gold"},
+}
+
+// currencyRegistry holds the known currencies, ISO 4217 and the registered custom units
+var currencyRegistry = struct {
+	sync.RWMutex
+	byCode map[string]Currency
+}{
+	byCode: func() map[string]Currency {
+		byCode := make(map[string]Currency, len(iso4217))
+		for _, c := range iso4217 {
+			byCode[c.Code] = c
+		}
+		return byCode
+	}(),
+}
+
+// LookupCurrency returns the currency registered under code, codes are case-sensitive
+func LookupCurrency(code string) (Currency, bool) {
+	currencyRegistry.RLock()
+	defer currencyRegistry.RUnlock()
+
+	c, ok := currencyRegistry.byCode[code]
+	return c, ok
+}
+
+// Currencies returns all the registered currencies, in no particular order
+func Currencies() []Currency {
+	currencyRegistry.RLock()
+	defer currencyRegistry.RUnlock()
+
+	currencies := make([]Currency, 0, len(currencyRegistry.byCode))
+	for _, c := range currencyRegistry.byCode {
+		currencies = append(currencies, c)
+	}
+
+	return currencies
+}
+
+// RegisterCurrency makes a custom unit, e.g. internal "CREDITS", known to NewMoney and the converters
+// registering a code that is already known is an error
+func RegisterCurrency(c Currency) error {
+	if c.Code == "" {
+		return errors.New("currency code cannot be empty")
+	}
+	if c.MinorUnits < 0 || c.MinorUnits > 9 {
+		return fmt.Errorf("minor units of %s must be between 0 and 9: %d", c.Code, c.MinorUnits)
+	}
+
+	currencyRegistry.Lock()
+	defer currencyRegistry.Unlock()
+
+	if _, ok := currencyRegistry.byCode[c.Code]; ok {
+		return fmt.Errorf("currency %s is already registered", c.Code)
+	}
+	currencyRegistry.byCode[c.Code] = c
+
+	return nil
+}
+
+// unregisterCurrency removes a currency added with RegisterCurrency, it lets tests restore the registry
+func unregisterCurrency(code string) {
+	currencyRegistry.Lock()
+	defer currencyRegistry.Unlock()
+
+	delete(currencyRegistry.byCode, code)
+}
+
+// validateCurrency returns ErrUnknownCurrency for a code that is not registered
+func validateCurrency(code string) error {
+	if _, ok := LookupCurrency(code); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+
+	return nil
+}
+
+// minorUnits returns the number of decimal places of a currency, 2 for an unknown one
+func minorUnits(code string) int {
+	if c, ok := LookupCurrency(code); ok {
+		return c.MinorUnits
+	}
+
+	return 2
+}
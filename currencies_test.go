@@ -0,0 +1,102 @@
+package aicost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LookupCurrency(t *testing.T) {
+	tests := []struct {
+		code   string
+		want   Currency
+		wantOk bool
+	}{
+		{code: "USD", want: Currency{Code: "USD", Numeric: 840, MinorUnits: 2, Symbol: "$", Name: "US Dollar"}, wantOk: true},
+		{code: "EUR", want: Currency{Code: "EUR", Numeric: 978, MinorUnits: 2, Symbol: "€", Name: "Euro"}, wantOk: true},
+		{code: "JPY", want: Currency{Code: "JPY", Numeric: 392, MinorUnits: 0, Symbol: "¥", Name: "Yen"}, wantOk: true},
+		{code: "XYZ", wantOk: false},
+		{code: "usd", wantOk: false},
+		{code: "", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, ok := LookupCurrency(tt.code)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Currencies_ISO4217(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, c := range iso4217 {
+		assert.Len(t, c.Code, 3, c.Code)
+		assert.False(t, codes[c.Code], "duplicate code %s", c.Code)
+		assert.Positive(t, c.Numeric, c.Code)
+		assert.True(t, c.MinorUnits >= 0 && c.MinorUnits <= 4, c.Code)
+		assert.NotEmpty(t, c.Name, c.Code)
+		codes[c.Code] = true
+	}
+
+	assert.GreaterOrEqual(t, len(Currencies()), len(iso4217))
+}
+
+func Test_minorUnits(t *testing.T) {
+	tests := []struct {
+		code string
+		want int
+	}{
+		{code: "USD", want: 2},
+		{code: "JPY", want: 0},
+		{code: "KWD", want: 3},
+		{code: "CLF", want: 4},
+		{code: "XYZ", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.want, minorUnits(tt.code))
+		})
+	}
+}
+
+func Test_RegisterCurrency(t *testing.T) {
+	_, err := NewMoney("TESTCREDITS", 10, 0)
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+
+	err = RegisterCurrency(Currency{Code: "TESTCREDITS", MinorUnits: 0, Name: "Test credits"})
+	assert.NoError(t, err)
+	t.Cleanup(func() { unregisterCurrency("TESTCREDITS") })
+
+	got, err := NewMoney("TESTCREDITS", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 10, CurrencyCode: "TESTCREDITS"}, got)
+	assert.Equal(t, 0, minorUnits("TESTCREDITS"))
+
+	converter := NewConverter("TESTCREDITS", map[string]float64{"USD": 0.01})
+	converted, err := converter.Convert(*got, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 100000000, CurrencyCode: "USD"}, converted)
+
+	tests := []struct {
+		name     string
+		currency Currency
+	}{
+		{name: "already registered", currency: Currency{Code: "TESTCREDITS"}},
+		{name: "iso code", currency: Currency{Code: "USD", MinorUnits: 2}},
+		{name: "empty code", currency: Currency{Code: ""}},
+		{name: "negative minor units", currency: Currency{Code: "TESTNEG", MinorUnits: -1}},
+		{name: "too many minor units", currency: Currency{Code: "TESTBIG", MinorUnits: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, RegisterCurrency(tt.currency))
+		})
+	}
+
+	_, ok := LookupCurrency("TESTNEG")
+	assert.False(t, ok)
+}
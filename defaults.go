@@ -0,0 +1,49 @@
+package aicost
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+)
+
+// defaultCatalogData is the catalog shipped with the package, see catalogs/default.json
+//
+//go:embed catalogs/default.json
+var defaultCatalogData []byte
+
+var defaultCatalog = sync.OnceValues(func() (*Catalog, error) {
+	return ParseCatalog(defaultCatalogData, CatalogJSON)
+})
+
+// DefaultCatalog returns the built-in price catalog of the major providers,
+// check AsOf for the date the prices were last updated
+func DefaultCatalog() (*Catalog, error) {
+	catalog, err := defaultCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default catalog: %w", err)
+	}
+
+	// a deep copy, callers may change the optional prices and tiers without reaching the cached catalog
+	return &Catalog{
+		Version: catalog.Version,
+		AsOf:    catalog.AsOf,
+		Models:  cloneModels(catalog.Models),
+	}, nil
+}
+
+// DefaultModels returns the models of the built-in catalog
+// it panics if the embedded catalog is invalid, which the package tests rule out
+func DefaultModels() []Model {
+	catalog, err := DefaultCatalog()
+	if err != nil {
+		panic(err)
+	}
+
+	return catalog.Models
+}
+
+// DefaultModelsWith returns the models of the built-in catalog with local overrides merged in,
+// see MergeModels
+func DefaultModelsWith(overrides []Model) []Model {
+	return MergeModels(DefaultModels(), overrides)
+}
@@ -0,0 +1,129 @@
+package aicost
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DefaultCatalog(t *testing.T) {
+	catalog, err := DefaultCatalog()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, catalog.Version)
+
+	asOf, err := catalog.AsOfDate()
+	assert.NoError(t, err)
+	assert.False(t, asOf.IsZero())
+
+	providers := map[string]bool{}
+	for _, m := range catalog.Models {
+		providers[m.Provider] = true
+	}
+	for _, provider := range []string{"openai", "anthropic", "google", "mistral"} {
+		assert.True(t, providers[provider], "missing provider %s", provider)
+	}
+}
+
+func Test_DefaultModels_Copy(t *testing.T) {
+	models := DefaultModels()
+	models[0].CostInput = Money{Units: 100, CurrencyCode: "USD"}
+
+	assert.NotEqual(t, models[0].CostInput, DefaultModels()[0].CostInput)
+
+	// the optional prices, aliases and tiers are copied too
+	for i := range models {
+		if models[i].CostCachedInput != nil {
+			models[i].CostCachedInput.Units = 100
+		}
+		if len(models[i].Aliases) > 0 {
+			models[i].Aliases[0] = "changed"
+		}
+		if len(models[i].Tiers) > 0 {
+			models[i].Tiers[0].CostInput.Units = 100
+		}
+	}
+	for _, m := range DefaultModels() {
+		if m.CostCachedInput != nil {
+			assert.NotEqual(t, int64(100), m.CostCachedInput.Units, m.Model)
+		}
+		if len(m.Aliases) > 0 {
+			assert.NotEqual(t, "changed", m.Aliases[0], m.Model)
+		}
+		if len(m.Tiers) > 0 {
+			assert.NotEqual(t, int64(100), m.Tiers[0].CostInput.Units, m.Model)
+		}
+	}
+}
+
+func Test_DefaultModelsWith(t *testing.T) {
+	overrides := []Model{
+		{
+			Provider:   "openai",
+			Model:      "gpt-4o",
+			CostInput:  Money{Units: 0, Nanos: 1, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 2, CurrencyCode: "USD"},
+		},
+		{
+			Provider:   "openai",
+			Model:      "gpt-9",
+			CostInput:  Money{Units: 0, Nanos: 3, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 4, CurrencyCode: "USD"},
+		},
+	}
+
+	models := DefaultModelsWith(overrides)
+	assert.Len(t, models, len(DefaultModels())+1)
+
+	accountant := NewAccountant(models, NewConverter("USD", testRates), false)
+	assert.Equal(t, &overrides[0], accountant.findModel("openai", "gpt-4o"))
+	assert.Equal(t, &overrides[1], accountant.findModel("openai", "gpt-9"))
+}
+
+func Test_NewAccountant_DefaultCatalog(t *testing.T) {
+	accountant := NewAccountant(nil, NewConverter("USD", testRates), false)
+
+	// gpt-4o input is $2.50 per 1M tokens
+	cost, _, err := accountant.CostForModelInput("openai", "gpt-4o", "USD", 1000000)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 2, Nanos: 500000000, CurrencyCode: "USD"}, cost)
+
+	// claude-sonnet-4 switches to long context prices above 200k prompt tokens
+	breakdown, err := accountant.CostForUsage("anthropic", "claude-sonnet-4", "USD", Usage{InputTokens: 250000})
+	assert.NoError(t, err)
+	assert.Equal(t, "long-context", breakdown.Tier)
+	assert.Equal(t, &Money{Units: 1, Nanos: 500000000, CurrencyCode: "USD"}, breakdown.Total.Cost)
+
+	// gpt-4o-mini cached input is $0.075 per 1M tokens, the catalog prices are per 1M tokens
+	breakdown, err = accountant.CostForUsage("openai", "gpt-4o-mini", "USD", Usage{CachedInputTokens: 1000000})
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 75000000, CurrencyCode: "USD"}, breakdown.Total.Cost)
+}
+
+func Test_DefaultCatalog_Aliases(t *testing.T) {
+	accountant := NewAccountant(nil, NewConverter("USD", testRates), false)
+
+	for _, model := range []string{"claude-sonnet-4-20250514", "claude-sonnet-4-0", "claude-3-7-sonnet-latest"} {
+		_, _, err := accountant.ResolveModel("anthropic", model, time.Now())
+		assert.NoError(t, err, model)
+	}
+}
+
+// defaultCatalogHashes records the content of every released version of catalogs/default.json,
+// an edit of the catalog needs a new version and a new entry here
+var defaultCatalogHashes = map[string]string{
+	"2025.09.2": "bd0d7bcffdb38fb8e183c6c9a964cf6759343975f5c286a0b840a49cd1530081",
+}
+
+func Test_DefaultCatalog_Version(t *testing.T) {
+	catalog, err := DefaultCatalog()
+	assert.NoError(t, err)
+
+	sum := sha256.Sum256(defaultCatalogData)
+	want, ok := defaultCatalogHashes[catalog.Version]
+	if assert.True(t, ok, "catalog version %s is not recorded, add it to defaultCatalogHashes", catalog.Version) {
+		assert.Equal(t, want, hex.EncodeToString(sum[:]), "catalogs/default.json changed without a version bump")
+	}
+}
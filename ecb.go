@@ -0,0 +1,156 @@
+package aicost
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidRates = errors.New("invalid conversion rates")
+
+// ECBRates are the euro reference rates of the European Central Bank,
+// read from eurofxref-daily.xml, eurofxref-hist.xml or eurofxref-hist-90d.xml
+type ECBRates struct {
+	// Days holds the rates of every published day, newest first
+	Days []DatedRates
+	// Skipped holds the currencies left out because they are not registered, e.g. CYP of the historical series
+	Skipped []string
+}
+
+// DatedRates are the rates of the base currency on a date
+type DatedRates struct {
+	Date  time.Time
+	Rates map[string]float64
+}
+
+// ecbEnvelope is the layout shared by the ECB daily and historical files
+type ecbEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Cube    struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// LoadECBRates reads an ECB reference rates file, e.g. a cached copy of eurofxref-hist.xml
+func LoadECBRates(path string) (*ECBRates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ECB rates %s: %w", path, err)
+	}
+	defer f.Close()
+
+	rates, err := ReadECBRates(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return rates, nil
+}
+
+// ReadECBRates reads the ECB reference rates XML, daily or historical
+func ReadECBRates(r io.Reader) (*ECBRates, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode ECB rates: %w", ErrInvalidRates, err)
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return nil, fmt.Errorf("%w: ECB rates have no days", ErrInvalidRates)
+	}
+
+	result := &ECBRates{}
+	seenDays := make(map[string]bool, len(envelope.Cube.Days))
+	skipped := map[string]bool{}
+
+	for _, day := range envelope.Cube.Days {
+		date, err := time.Parse(time.DateOnly, day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid ECB rates date %q: %w", ErrInvalidRates, day.Time, err)
+		}
+		if seenDays[day.Time] {
+			return nil, fmt.Errorf("%w: ECB rates of %s are listed twice", ErrInvalidRates, day.Time)
+		}
+		seenDays[day.Time] = true
+
+		rates := make(map[string]float64, len(day.Rates))
+		for _, r := range day.Rates {
+			rate, err := strconv.ParseFloat(r.Rate, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid ECB rate of %s on %s: %q", ErrInvalidRates, r.Currency, day.Time, r.Rate)
+			}
+			if _, err := rateRat(r.Currency, rate); err != nil {
+				return nil, fmt.Errorf("%w: ECB rates of %s: %w", ErrInvalidRates, day.Time, err)
+			}
+			if _, ok := rates[r.Currency]; ok {
+				return nil, fmt.Errorf("%w: ECB rate of %s on %s is listed twice", ErrInvalidRates, r.Currency, day.Time)
+			}
+			if validateCurrency(r.Currency) != nil {
+				skipped[r.Currency] = true
+				continue
+			}
+			rates[r.Currency] = rate
+		}
+		if len(rates) == 0 {
+			return nil, fmt.Errorf("%w: ECB rates of %s have no registered currency", ErrInvalidRates, day.Time)
+		}
+
+		result.Days = append(result.Days, DatedRates{Date: date, Rates: rates})
+	}
+
+	sort.Slice(result.Days, func(i, j int) bool {
+		return result.Days[i].Date.After(result.Days[j].Date)
+	})
+	for cur := range skipped {
+		result.Skipped = append(result.Skipped, cur)
+	}
+	sort.Strings(result.Skipped)
+
+	return result, nil
+}
+
+// Latest returns the rates of the newest day
+func (e *ECBRates) Latest() DatedRates {
+	return e.Days[0]
+}
+
+// Apply sets the rates of the newest day as the current rates of c and every day as its dated rates, see RatesAt
+// the base currency of c must be EUR
+func (e *ECBRates) Apply(c *converter) error {
+	if c.baseCurrency != CurrencyEUR {
+		return fmt.Errorf("ECB rates are for the base currency %s, not %s", CurrencyEUR, c.baseCurrency)
+	}
+	if len(e.Days) == 0 {
+		return fmt.Errorf("%w: ECB rates have no days", ErrInvalidRates)
+	}
+
+	if err := c.Rates(e.Latest().Rates); err != nil {
+		return err
+	}
+	for _, day := range e.Days {
+		if err := c.RatesAt(day.Date, day.Rates); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Converter returns a converter with the base currency EUR and the rates applied, see Apply
+func (e *ECBRates) Converter() (*converter, error) {
+	c := NewConverter(CurrencyEUR, nil)
+	if err := e.Apply(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,192 @@
+package aicost
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ecbDaily = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time='2025-03-14'>
+			<Cube currency='USD' rate='1.0885'/>
+			<Cube currency='JPY' rate='161.79'/>
+			<Cube currency='GBP' rate='0.84015'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbHist = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2025-03-14">
+			<Cube currency="USD" rate="1.0885"/>
+			<Cube currency="GBP" rate="0.84015"/>
+		</Cube>
+		<Cube time="2025-03-13">
+			<Cube currency="USD" rate="1.0857"/>
+			<Cube currency="GBP" rate="0.8387"/>
+		</Cube>
+		<Cube time="2007-12-31">
+			<Cube currency="USD" rate="1.4721"/>
+			<Cube currency="CYP" rate="0.585274"/>
+			<Cube currency="MTL" rate="0.4293"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func Test_ReadECBRates(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		xml     string
+		want    *ECBRates
+		wantErr bool
+	}{
+		{
+			name: "daily",
+			xml:  ecbDaily,
+			want: &ECBRates{
+				Days: []DatedRates{
+					{Date: date("2025-03-14"), Rates: map[string]float64{"USD": 1.0885, "JPY": 161.79, "GBP": 0.84015}},
+				},
+			},
+		},
+		{
+			name: "historical",
+			xml:  ecbHist,
+			want: &ECBRates{
+				Days: []DatedRates{
+					{Date: date("2025-03-14"), Rates: map[string]float64{"USD": 1.0885, "GBP": 0.84015}},
+					{Date: date("2025-03-13"), Rates: map[string]float64{"USD": 1.0857, "GBP": 0.8387}},
+					{Date: date("2007-12-31"), Rates: map[string]float64{"USD": 1.4721}},
+				},
+				Skipped: []string{"CYP", "MTL"},
+			},
+		},
+		{
+			name:    "not xml",
+			xml:     `{"USD": 1.0885}`,
+			wantErr: true,
+		},
+		{
+			name:    "no days",
+			xml:     `<Envelope><Cube></Cube></Envelope>`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid date",
+			xml:     `<Envelope><Cube><Cube time="14.03.2025"><Cube currency="USD" rate="1.0885"/></Cube></Cube></Envelope>`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid rate",
+			xml:     `<Envelope><Cube><Cube time="2025-03-14"><Cube currency="USD" rate="1,0885"/></Cube></Cube></Envelope>`,
+			wantErr: true,
+		},
+		{
+			name:    "zero rate",
+			xml:     `<Envelope><Cube><Cube time="2025-03-14"><Cube currency="USD" rate="0"/></Cube></Cube></Envelope>`,
+			wantErr: true,
+		},
+		{
+			name: "day listed twice",
+			xml: `<Envelope><Cube>
+				<Cube time="2025-03-14"><Cube currency="USD" rate="1.0885"/></Cube>
+				<Cube time="2025-03-14"><Cube currency="USD" rate="1.0886"/></Cube>
+			</Cube></Envelope>`,
+			wantErr: true,
+		},
+		{
+			name:    "currency listed twice",
+			xml:     `<Envelope><Cube><Cube time="2025-03-14"><Cube currency="USD" rate="1.0885"/><Cube currency="USD" rate="1.0886"/></Cube></Cube></Envelope>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadECBRates(strings.NewReader(tt.xml))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRates)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_LoadECBRates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eurofxref-hist.xml")
+	assert.NoError(t, os.WriteFile(path, []byte(ecbHist), 0o600))
+
+	rates, err := LoadECBRates(path)
+	assert.NoError(t, err)
+	assert.Len(t, rates.Days, 3)
+	assert.Equal(t, "2025-03-14", rates.Latest().Date.Format(time.DateOnly))
+
+	_, err = LoadECBRates(filepath.Join(t.TempDir(), "missing.xml"))
+	assert.Error(t, err)
+}
+
+func Test_ECBRates_Converter(t *testing.T) {
+	rates, err := ReadECBRates(strings.NewReader(ecbHist))
+	assert.NoError(t, err)
+
+	c, err := rates.Converter()
+	assert.NoError(t, err)
+
+	// the newest rates are the current ones
+	got, err := c.Convert(Money{Units: 100, CurrencyCode: "EUR"}, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 108, Nanos: 850000000, CurrencyCode: "USD"}, got)
+
+	// 100 / 1.0857 * 0.8387
+	got, err = c.ConvertAt(Money{Units: 100, CurrencyCode: "USD"}, "GBP", time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 77, Nanos: 249700654, CurrencyCode: "GBP"}, got)
+
+	// a weekend uses the rates of friday
+	got, err = c.ConvertAt(Money{Units: 100, CurrencyCode: "EUR"}, "USD", time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 108, Nanos: 850000000, CurrencyCode: "USD"}, got)
+
+	got, err = c.ConvertAt(Money{Units: 100, CurrencyCode: "EUR"}, "USD", time.Date(2007, 12, 31, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 147, Nanos: 210000000, CurrencyCode: "USD"}, got)
+}
+
+func Test_ECBRates_Apply(t *testing.T) {
+	rates, err := ReadECBRates(strings.NewReader(ecbDaily))
+	assert.NoError(t, err)
+
+	assert.Error(t, rates.Apply(NewConverter(CurrencyUSD, nil)))
+	assert.Error(t, (&ECBRates{}).Apply(NewConverter(CurrencyEUR, nil)))
+
+	c := NewConverter(CurrencyEUR, nil)
+	assert.NoError(t, rates.Apply(c))
+	assert.Equal(t, rates.Latest().Rates, c.rates)
+	assert.Equal(t, rates.Latest().Rates, c.history["2025-03-14"])
+}
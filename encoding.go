@@ -0,0 +1,166 @@
+package aicost
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	_ driver.Valuer            = Money{}
+	_ sql.Scanner              = (*Money)(nil)
+	_ encoding.TextMarshaler   = Money{}
+	_ encoding.TextUnmarshaler = (*Money)(nil)
+	_ json.Marshaler           = Money{}
+	_ json.Unmarshaler         = (*Money)(nil)
+	_ json.Marshaler           = DecimalMoney{}
+)
+
+// moneyFields has the fields of Money without its methods, it encodes the units/nanos object form
+type moneyFields Money
+
+// MarshalText writes the canonical decimal form of m, the currency code and the shortest exact amount,
+// e.g. "USD 0.003" or "EUR -12.5"
+func (m Money) MarshalText() ([]byte, error) {
+	if m.CurrencyCode == "" {
+		return nil, errors.New("currency code cannot be empty")
+	}
+
+	return []byte(m.CurrencyCode + " " + formatDecimal(m.totalNanos())), nil
+}
+
+// UnmarshalText parses the canonical decimal form written by MarshalText
+// the amount has no group separators and at most 9 decimal places, it is never rounded
+func (m *Money) UnmarshalText(text []byte) error {
+	code, number, ok := strings.Cut(string(text), " ")
+	if !ok {
+		return fmt.Errorf("%w %q: expected a currency code and an amount", ErrInvalidAmount, text)
+	}
+
+	negative := strings.HasPrefix(number, "-")
+	total, err := parseDecimal(strings.TrimPrefix(number, "-"), ".", "")
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidAmount, text, err)
+	}
+	if negative {
+		total.Neg(total)
+	}
+
+	money, err := moneyFromNanos(code, total)
+	if err != nil {
+		return fmt.Errorf("failed to parse %q: %w", text, err)
+	}
+	*m = *money
+
+	return nil
+}
+
+// MarshalJSON writes m as an object with units, nanos and currency_code, see DecimalMoney for the string form
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyFields(m))
+}
+
+// UnmarshalJSON reads the units/nanos object form or the canonical decimal string
+// the object form is not validated and other fields in it are ignored, the string form is validated, see UnmarshalText
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var text string
+		if err := json.Unmarshal(trimmed, &text); err != nil {
+			return err
+		}
+		return m.UnmarshalText([]byte(text))
+	}
+
+	var fields moneyFields
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return err
+	}
+	*m = Money(fields)
+
+	return nil
+}
+
+// MarshalYAML writes m as a mapping with units, nanos and currency_code, like the JSON object form
+// a YAML string in the canonical decimal form is read through UnmarshalText
+func (m Money) MarshalYAML() (interface{}, error) {
+	return moneyFields(m), nil
+}
+
+// Value stores m as its canonical decimal text, see MarshalText
+func (m Money) Value() (driver.Value, error) {
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+// Scan reads the canonical decimal text of a string or bytes column
+// NULL is an error, scan nullable columns into sql.Null[Money]
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return m.UnmarshalText([]byte(v))
+	case []byte:
+		return m.UnmarshalText(v)
+	case nil:
+		return errors.New("cannot scan NULL into Money, use sql.Null[Money]")
+	}
+
+	return fmt.Errorf("cannot scan %T into Money", src)
+}
+
+// DecimalMoney is Money written to JSON and YAML as its compact canonical decimal string, e.g. "USD 0.003"
+// it reads both the string and the object forms
+type DecimalMoney struct {
+	Money `yaml:",inline"`
+}
+
+// MarshalJSON writes d as a JSON string, see Money.MarshalText
+func (d DecimalMoney) MarshalJSON() ([]byte, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+// MarshalYAML writes d as a YAML string, see Money.MarshalText
+func (d DecimalMoney) MarshalYAML() (interface{}, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+// formatDecimal writes an amount of nanos in units with the fewest decimals that keep it exact
+func formatDecimal(total *big.Int) string {
+	units, nanos := new(big.Int).QuoRem(new(big.Int).Abs(total), nanosPerUnit, new(big.Int))
+
+	sign := ""
+	if total.Sign() < 0 {
+		sign = "-"
+	}
+
+	decimals := strings.TrimRight(fmt.Sprintf("%09d", nanos.Int64()), "0")
+	if decimals == "" {
+		return sign + units.String()
+	}
+
+	return sign + units.String() + "." + decimals
+}
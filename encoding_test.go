@@ -0,0 +1,338 @@
+package aicost
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_Money_MarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		want    string
+		wantErr bool
+	}{
+		{name: "fraction", money: Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"}, want: "USD 0.003"},
+		{name: "whole units", money: Money{Units: 12, Nanos: 0, CurrencyCode: "EUR"}, want: "EUR 12"},
+		{name: "one nano", money: Money{Units: 0, Nanos: 1, CurrencyCode: "USD"}, want: "USD 0.000000001"},
+		{name: "negative", money: Money{Units: -12, Nanos: -500000000, CurrencyCode: "EUR"}, want: "EUR -12.5"},
+		{name: "negative nanos only", money: Money{Units: 0, Nanos: -250000000, CurrencyCode: "USD"}, want: "USD -0.25"},
+		{name: "zero", money: Money{CurrencyCode: "JPY"}, want: "JPY 0"},
+		{name: "largest", money: Money{Units: 9223372036854775807, Nanos: 999999999, CurrencyCode: "USD"}, want: "USD 9223372036854775807.999999999"},
+		{name: "empty currency", money: Money{Units: 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.MarshalText()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			var back Money
+			assert.NoError(t, back.UnmarshalText(got))
+			assert.Equal(t, tt.money, back)
+		})
+	}
+}
+
+func Test_Money_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Money
+		wantErr bool
+	}{
+		{name: "trailing zeros", text: "USD 12.50", want: Money{Units: 12, Nanos: 500000000, CurrencyCode: "USD"}},
+		{name: "negative", text: "USD -0.5", want: Money{Units: 0, Nanos: -500000000, CurrencyCode: "USD"}},
+		{name: "missing amount", text: "USD", wantErr: true},
+		{name: "unknown currency", text: "XYZ 1", wantErr: true},
+		{name: "symbol", text: "$1", wantErr: true},
+		{name: "group separator", text: "USD 1,000", wantErr: true},
+		{name: "too many decimals", text: "USD 0.0000000001", wantErr: true},
+		{name: "double space", text: "USD  1", wantErr: true},
+		{name: "overflow", text: "USD 9223372036854775808", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.UnmarshalText([]byte(tt.text))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_JSON(t *testing.T) {
+	m := Money{Units: 1, Nanos: 500000000, CurrencyCode: "USD"}
+
+	// the object form stays the default
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"units": 1, "nanos": 500000000, "currency_code": "USD"}`, string(data))
+
+	data, err = json.Marshal(DecimalMoney{m})
+	assert.NoError(t, err)
+	assert.Equal(t, `"USD 1.5"`, string(data))
+
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{name: "object", data: `{"units": 1, "nanos": 500000000, "currency_code": "USD"}`, want: m},
+		{name: "string", data: `"USD 1.5"`, want: m},
+		{name: "null", data: `null`, want: Money{}},
+		{name: "other fields are ignored", data: `{"units": 1, "nanos": 500000000, "currency_code": "USD", "display": "$1.50"}`, want: m},
+		{name: "invalid string", data: `"1.5 dollars"`, wantErr: true},
+		{name: "number", data: `1.5`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			var decimal DecimalMoney
+			assert.NoError(t, json.Unmarshal([]byte(tt.data), &decimal))
+			assert.Equal(t, tt.want, decimal.Money)
+		})
+	}
+}
+
+func Test_Money_YAML(t *testing.T) {
+	m := Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"}
+
+	data, err := yaml.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, "units: 0\nnanos: 2500\ncurrency_code: USD\n", string(data))
+
+	data, err = yaml.Marshal(DecimalMoney{m})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD 0.0000025\n", string(data))
+
+	for _, doc := range []string{"units: 0\nnanos: 2500\ncurrency_code: USD\n", "USD 0.0000025\n"} {
+		var got Money
+		assert.NoError(t, yaml.Unmarshal([]byte(doc), &got), doc)
+		assert.Equal(t, m, got, doc)
+
+		var decimal DecimalMoney
+		assert.NoError(t, yaml.Unmarshal([]byte(doc), &decimal), doc)
+		assert.Equal(t, m, decimal.Money, doc)
+	}
+}
+
+func Test_Money_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Money
+		wantErr bool
+	}{
+		{name: "string", src: "EUR 0.85", want: Money{Units: 0, Nanos: 850000000, CurrencyCode: "EUR"}},
+		{name: "bytes", src: []byte("EUR 0.85"), want: Money{Units: 0, Nanos: 850000000, CurrencyCode: "EUR"}},
+		{name: "null", src: nil, wantErr: true},
+		{name: "float", src: 0.85, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_SQL_RoundTrip(t *testing.T) {
+	db := openMemoryDB(t)
+
+	amounts := []Money{
+		{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+		{Units: -12, Nanos: -500000000, CurrencyCode: "EUR"},
+		{Units: 1500, Nanos: 0, CurrencyCode: "JPY"},
+	}
+	for _, m := range amounts {
+		_, err := db.Exec("INSERT", m)
+		assert.NoError(t, err)
+	}
+	_, err := db.Exec("INSERT", nil)
+	assert.NoError(t, err)
+
+	rows, err := db.Query("SELECT")
+	assert.NoError(t, err)
+	defer rows.Close()
+
+	var got []Money
+	var nulls int
+	for rows.Next() {
+		var m sql.Null[Money]
+		assert.NoError(t, rows.Scan(&m))
+		if !m.Valid {
+			nulls++
+			continue
+		}
+		got = append(got, m.V)
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, amounts, got)
+	assert.Equal(t, 1, nulls)
+
+	// a NULL cannot be scanned into a plain Money
+	nullDB := openMemoryDB(t)
+	_, err = nullDB.Exec("INSERT", nil)
+	assert.NoError(t, err)
+
+	var m Money
+	assert.Error(t, nullDB.QueryRow("SELECT").Scan(&m))
+}
+
+// memoryDriver is a database/sql driver storing the values of a single column in memory,
+// it runs two statements: INSERT with one argument and SELECT
+type memoryDriver struct {
+	mu     sync.Mutex
+	tables map[string][]driver.Value
+}
+
+var (
+	testMemoryDriver = &memoryDriver{tables: make(map[string][]driver.Value)}
+	memoryTables     atomic.Int64
+)
+
+func init() {
+	sql.Register("aicost-memory", testMemoryDriver)
+}
+
+// openMemoryDB opens a new empty table, dropped when the test ends
+func openMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	name := fmt.Sprintf("%s/%d", t.Name(), memoryTables.Add(1))
+	db, err := sql.Open("aicost-memory", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+		testMemoryDriver.mu.Lock()
+		defer testMemoryDriver.mu.Unlock()
+		delete(testMemoryDriver.tables, name)
+	})
+
+	return db
+}
+
+func (d *memoryDriver) Open(name string) (driver.Conn, error) {
+	return &memoryConn{driver: d, table: name}, nil
+}
+
+type memoryConn struct {
+	driver *memoryDriver
+	table  string
+}
+
+func (c *memoryConn) Prepare(query string) (driver.Stmt, error) {
+	if query != "INSERT" && query != "SELECT" {
+		return nil, errors.New("unsupported query " + query)
+	}
+
+	return &memoryStmt{conn: c, query: query}, nil
+}
+
+func (c *memoryConn) Close() error { return nil }
+
+func (c *memoryConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type memoryStmt struct {
+	conn  *memoryConn
+	query string
+}
+
+func (s *memoryStmt) Close() error { return nil }
+
+func (s *memoryStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 1
+	}
+	return 0
+}
+
+func (s *memoryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	// the driver only stores the types database/sql may pass after calling driver.Valuer
+	if _, ok := args[0].(string); !ok && args[0] != nil {
+		return nil, errors.New("unexpected value type")
+	}
+
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tables[s.conn.table] = append(d.tables[s.conn.table], args[0])
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memoryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	values := make([]driver.Value, len(d.tables[s.conn.table]))
+	copy(values, d.tables[s.conn.table])
+	// bytes like most drivers return for text columns
+	for i, v := range values {
+		if text, ok := v.(string); ok {
+			values[i] = []byte(text)
+		}
+	}
+
+	return &memoryRows{values: values}, nil
+}
+
+type memoryRows struct {
+	values []driver.Value
+}
+
+func (r *memoryRows) Columns() []string { return []string{"cost"} }
+
+func (r *memoryRows) Close() error { return nil }
+
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package aicost
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Locale describes how a language and region write amounts of money
+type Locale struct {
+	// Tag is the BCP 47 tag of the locale, e.g. "en-US"
+	Tag string
+	// DecimalSeparator separates the units from the decimals
+	DecimalSeparator string
+	// GroupSeparator separates the groups of three digits of the units
+	GroupSeparator string
+	// SymbolAfter writes the currency after the amount, e.g. "1.234,56 €"
+	SymbolAfter bool
+	// SymbolSpace separates the currency symbol from the amount, a currency code is always separated
+	SymbolSpace bool
+}
+
+var (
+	LocaleEnUS = Locale{Tag: "en-US", DecimalSeparator: ".", GroupSeparator: ","}
+	LocaleEnGB = Locale{Tag: "en-GB", DecimalSeparator: ".", GroupSeparator: ","}
+	LocaleDeDE = Locale{Tag: "de-DE", DecimalSeparator: ",", GroupSeparator: ".", SymbolAfter: true, SymbolSpace: true}
+	LocaleFrFR = Locale{Tag: "fr-FR", DecimalSeparator: ",", GroupSeparator: "\u202f", SymbolAfter: true, SymbolSpace: true}
+)
+
+// MinorUnitsPlaces formats an amount with the minor units of its currency, e.g. 2 for USD or 0 for JPY
+const MinorUnitsPlaces = -1
+
+// FormatOptions controls how Format writes an amount
+type FormatOptions struct {
+	Locale Locale
+	// Places is the number of decimal places, from 0 to 9, or MinorUnitsPlaces
+	Places int
+	// Rounding drops the decimal places beyond Places
+	Rounding RoundingMode
+	// Code writes the currency code instead of its symbol, e.g. "USD 12.50"
+	// currencies without a symbol are always written with their code
+	Code bool
+}
+
+// preferredSymbols resolves the symbols shared by several currencies, or likely to be, when parsing
+var preferredSymbols = map[string]string{
+	"$": CurrencyUSD,
+	"€": CurrencyEUR,
+	"£": "GBP",
+	"¥": "JPY",
+}
+
+// Format writes m with the symbol, separators and precision of opts, e.g. "$0.0030" or "1.234,56 €"
+// MoneyToString keeps the fixed "USD 0.003000000" form
+func (m *Money) Format(opts FormatOptions) (string, error) {
+	places := opts.Places
+	if places == MinorUnitsPlaces {
+		places = minorUnits(m.CurrencyCode)
+	}
+
+	rounded, err := m.Round(places, opts.Rounding)
+	if err != nil {
+		return "", fmt.Errorf("failed to round %s: %w", MoneyToString(*m), err)
+	}
+
+	number := formatNanos(rounded.totalNanos(), places, opts.Locale)
+
+	currency, space := m.CurrencyCode, true
+	if c, ok := LookupCurrency(m.CurrencyCode); ok && c.Symbol != "" && c.Symbol != c.Code && !opts.Code {
+		currency, space = c.Symbol, opts.Locale.SymbolSpace
+	}
+	separator := ""
+	if space {
+		separator = " "
+	}
+
+	sign := ""
+	if rounded.totalNanos().Sign() < 0 {
+		sign = "-"
+	}
+
+	if opts.Locale.SymbolAfter {
+		return sign + number + separator + currency, nil
+	}
+
+	return sign + currency + separator + number, nil
+}
+
+// formatNanos writes the absolute value of an amount of nanos with places decimals and the locale separators
+func formatNanos(total *big.Int, places int, locale Locale) string {
+	units, nanos := new(big.Int).QuoRem(new(big.Int).Abs(total), nanosPerUnit, new(big.Int))
+
+	digits := units.String()
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(locale.GroupSeparator)
+		}
+		b.WriteRune(d)
+	}
+
+	if places > 0 {
+		b.WriteString(locale.DecimalSeparator)
+		b.WriteString(fmt.Sprintf("%09d", nanos.Int64())[:places])
+	}
+
+	return b.String()
+}
+
+// ParseMoney parses an amount written with a currency code or symbol in the en-US locale,
+// e.g. "USD 12.50", "12.50 USD", "€0.002" or "-$1,234.5", see ParseMoneyLocale
+func ParseMoney(s string) (*Money, error) {
+	return ParseMoneyLocale(s, LocaleEnUS)
+}
+
+// ParseMoneyLocale parses an amount written with the separators of locale, e.g. "1.234,56 €" in LocaleDeDE
+// the currency, a code or a symbol, is required before or after the number and the sign goes first or right before the digits
+// groups of the units must have three digits and more than 9 decimal places is an error, the amount is never rounded
+func ParseMoneyLocale(s string, locale Locale) (*Money, error) {
+	if locale.DecimalSeparator == "" {
+		return nil, fmt.Errorf("locale %q has no decimal separator", locale.Tag)
+	}
+
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return nil, fmt.Errorf("%w: empty string", ErrInvalidAmount)
+	}
+
+	negative := false
+	if strings.HasPrefix(rest, "-") {
+		negative = true
+		rest = rest[1:]
+	}
+
+	currency, rest := cutCurrencyPrefix(rest)
+	if currency != "" {
+		rest = strings.TrimPrefix(rest, " ")
+		if !negative && strings.HasPrefix(rest, "-") {
+			negative = true
+			rest = rest[1:]
+		}
+	}
+
+	end := numberEnd(rest, locale)
+	number, suffix := rest[:end], strings.TrimPrefix(rest[end:], " ")
+
+	if suffix != "" {
+		if currency != "" {
+			return nil, fmt.Errorf("%w %q: unexpected %q after the amount", ErrInvalidAmount, s, suffix)
+		}
+		code, tail := cutCurrencyPrefix(suffix)
+		if code == "" || tail != "" {
+			return nil, fmt.Errorf("%w %q: unknown currency %q", ErrInvalidAmount, s, suffix)
+		}
+		currency = code
+	}
+	if currency == "" {
+		return nil, fmt.Errorf("%w %q: missing currency", ErrInvalidAmount, s)
+	}
+
+	total, err := parseDecimal(number, locale.DecimalSeparator, locale.GroupSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidAmount, s, err)
+	}
+	if negative {
+		total.Neg(total)
+	}
+
+	money, err := moneyFromNanos(currency, total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", s, err)
+	}
+
+	return money, nil
+}
+
+// numberEnd returns the length of the digits and separators at the start of s
+func numberEnd(s string, locale Locale) int {
+	end := 0
+	for end < len(s) {
+		switch {
+		case isDigit(rune(s[end])):
+			end++
+		case locale.DecimalSeparator != "" && strings.HasPrefix(s[end:], locale.DecimalSeparator):
+			end += len(locale.DecimalSeparator)
+		case locale.GroupSeparator != "" && strings.HasPrefix(s[end:], locale.GroupSeparator):
+			// a group separator is part of the number only when digits follow, "1 234 €" ends with a space
+			next := end + len(locale.GroupSeparator)
+			if next == len(s) || !isDigit(rune(s[next])) {
+				return end
+			}
+			end = next
+		default:
+			return end
+		}
+	}
+
+	return end
+}
+
+// cutCurrencyPrefix returns the currency of a code or symbol at the start of s and the rest of s,
+// an empty currency when s does not start with one
+func cutCurrencyPrefix(s string) (string, string) {
+	// a code is a run of upper case letters, e.g. USD or a registered custom unit
+	end := 0
+	for end < len(s) && s[end] >= 'A' && s[end] <= 'Z' {
+		end++
+	}
+	if end > 0 {
+		if _, ok := LookupCurrency(s[:end]); ok {
+			return s[:end], s[end:]
+		}
+	}
+
+	// the longest symbol wins, e.g. "CA$" over "$", a symbol shared by currencies is used only when preferred
+	var code, symbol string
+	for sym, c := range preferredSymbols {
+		if strings.HasPrefix(s, sym) && len(sym) > len(symbol) {
+			code, symbol = c, sym
+		}
+	}
+	ambiguous := false
+	for _, c := range Currencies() {
+		if c.Symbol == "" || !strings.HasPrefix(s, c.Symbol) || preferredSymbols[c.Symbol] != "" {
+			continue
+		}
+		switch {
+		case len(c.Symbol) > len(symbol):
+			code, symbol, ambiguous = c.Code, c.Symbol, false
+		case c.Symbol == symbol && c.Code != code:
+			ambiguous = true
+		}
+	}
+	if symbol == "" || ambiguous {
+		return "", s
+	}
+
+	return code, s[len(symbol):]
+}
+
+// parseDecimal parses unsigned digits with an optional decimal part into an amount of nanos
+// group separators are only allowed between groups of three digits of the units
+func parseDecimal(s, decimalSeparator, groupSeparator string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing digits")
+	}
+
+	units, decimals, hasDecimals := strings.Cut(s, decimalSeparator)
+	if hasDecimals && decimals == "" {
+		return nil, errors.New("missing digits after the decimal separator")
+	}
+	if len(decimals) > 9 {
+		return nil, fmt.Errorf("more than 9 decimal places: %s", decimals)
+	}
+	if !allDigits(decimals) {
+		return nil, fmt.Errorf("invalid decimals %q", decimals)
+	}
+
+	if groupSeparator != "" && strings.Contains(units, groupSeparator) {
+		groups := strings.Split(units, groupSeparator)
+		for i, g := range groups {
+			if (i == 0 && (len(g) == 0 || len(g) > 3)) || (i > 0 && len(g) != 3) {
+				return nil, fmt.Errorf("invalid digit grouping %q", units)
+			}
+		}
+		units = strings.Join(groups, "")
+	}
+	if units == "" || !allDigits(units) {
+		return nil, fmt.Errorf("invalid units %q", units)
+	}
+
+	total, ok := new(big.Int).SetString(units+decimals+strings.Repeat("0", 9-len(decimals)), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+
+	return total, nil
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if !isDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
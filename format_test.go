@@ -0,0 +1,216 @@
+package aicost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Money_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		opts    FormatOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "symbol with four places",
+			money: Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 4},
+			want:  "$0.0030",
+		},
+		{
+			name:  "german locale",
+			money: Money{Units: 1234, Nanos: 560000000, CurrencyCode: "EUR"},
+			opts:  FormatOptions{Locale: LocaleDeDE, Places: 2},
+			want:  "1.234,56 €",
+		},
+		{
+			name:  "french locale",
+			money: Money{Units: 1234567, Nanos: 500000000, CurrencyCode: "EUR"},
+			opts:  FormatOptions{Locale: LocaleFrFR, Places: MinorUnitsPlaces},
+			want:  "1 234 567,50 €",
+		},
+		{
+			name:  "code instead of symbol",
+			money: Money{Units: 12, Nanos: 500000000, CurrencyCode: "USD"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 2, Code: true},
+			want:  "USD 12.50",
+		},
+		{
+			name:  "currency without symbol",
+			money: Money{Units: 1000, Nanos: 0, CurrencyCode: "CHF"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 2},
+			want:  "CHF 1,000.00",
+		},
+		{
+			name:  "minor units of yen",
+			money: Money{Units: 1500, Nanos: 500000000, CurrencyCode: "JPY"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: MinorUnitsPlaces},
+			want:  "¥1,500",
+		},
+		{
+			name:  "negative amount",
+			money: Money{Units: -1, Nanos: -250000000, CurrencyCode: "GBP"},
+			opts:  FormatOptions{Locale: LocaleEnGB, Places: 2},
+			want:  "-£1.25",
+		},
+		{
+			name:  "negative amount after",
+			money: Money{Units: -1, Nanos: -250000000, CurrencyCode: "EUR"},
+			opts:  FormatOptions{Locale: LocaleDeDE, Places: 2},
+			want:  "-1,25 €",
+		},
+		{
+			name:  "half even rounding",
+			money: Money{Units: 0, Nanos: 125000000, CurrencyCode: "USD"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 2},
+			want:  "$0.12",
+		},
+		{
+			name:  "ceiling rounding",
+			money: Money{Units: 0, Nanos: 121000000, CurrencyCode: "USD"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 2, Rounding: RoundCeiling},
+			want:  "$0.13",
+		},
+		{
+			name:  "rounded to zero is not negative",
+			money: Money{Units: 0, Nanos: -1000, CurrencyCode: "USD"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 2},
+			want:  "$0.00",
+		},
+		{
+			name:  "nine places",
+			money: Money{Units: 0, Nanos: 3000000, CurrencyCode: "USD"},
+			opts:  FormatOptions{Locale: LocaleEnUS, Places: 9, Code: true},
+			want:  "USD 0.003000000",
+		},
+		{
+			name:    "too many places",
+			money:   Money{Units: 1, CurrencyCode: "USD"},
+			opts:    FormatOptions{Locale: LocaleEnUS, Places: 10},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Format(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ParseMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Money
+		wantErr bool
+	}{
+		{name: "code before", input: "USD 12.50", want: &Money{Units: 12, Nanos: 500000000, CurrencyCode: "USD"}},
+		{name: "code after", input: "12.50 USD", want: &Money{Units: 12, Nanos: 500000000, CurrencyCode: "USD"}},
+		{name: "code without space", input: "USD12", want: &Money{Units: 12, CurrencyCode: "USD"}},
+		{name: "euro symbol", input: "€0.002", want: &Money{Units: 0, Nanos: 2000000, CurrencyCode: "EUR"}},
+		{name: "dollar symbol", input: "$1,234.5", want: &Money{Units: 1234, Nanos: 500000000, CurrencyCode: "USD"}},
+		{name: "longest symbol", input: "CA$3", want: &Money{Units: 3, CurrencyCode: "CAD"}},
+		{name: "symbol after", input: "10 ₹", want: &Money{Units: 10, CurrencyCode: "INR"}},
+		{name: "nine decimals", input: "USD 0.000000001", want: &Money{Units: 0, Nanos: 1, CurrencyCode: "USD"}},
+		{name: "sign first", input: "-$1.25", want: &Money{Units: -1, Nanos: -250000000, CurrencyCode: "USD"}},
+		{name: "sign after the code", input: "USD -1.25", want: &Money{Units: -1, Nanos: -250000000, CurrencyCode: "USD"}},
+		{name: "sign before the digits", input: "-1.25 USD", want: &Money{Units: -1, Nanos: -250000000, CurrencyCode: "USD"}},
+		{name: "surrounding spaces", input: "  USD 1  ", want: &Money{Units: 1, CurrencyCode: "USD"}},
+		{name: "exact beyond float64", input: "USD 9007199254740993.000000001", want: &Money{Units: 9007199254740993, Nanos: 1, CurrencyCode: "USD"}},
+		{name: "empty", input: "", wantErr: true},
+		{name: "missing currency", input: "12.50", wantErr: true},
+		{name: "unknown code", input: "XYZ 12.50", wantErr: true},
+		{name: "ambiguous symbol", input: "kr 10", wantErr: true},
+		{name: "two currencies", input: "USD 12.50 EUR", wantErr: true},
+		{name: "too many decimals", input: "USD 0.0000000001", wantErr: true},
+		{name: "missing digits", input: "USD", wantErr: true},
+		{name: "missing decimals", input: "USD 12.", wantErr: true},
+		{name: "bad grouping", input: "$1,23.5", wantErr: true},
+		{name: "group in decimals", input: "$1.234,5", wantErr: true},
+		{name: "two decimal separators", input: "$1.2.3", wantErr: true},
+		{name: "double sign", input: "--$1", wantErr: true},
+		{name: "exponent", input: "USD 1e3", wantErr: true},
+		{name: "lower case code", input: "usd 1", wantErr: true},
+		{name: "overflow", input: "USD 9223372036854775808", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ParseMoneyLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		locale  Locale
+		want    *Money
+		wantErr bool
+		errIs   error
+	}{
+		{name: "german", input: "1.234,56 €", locale: LocaleDeDE, want: &Money{Units: 1234, Nanos: 560000000, CurrencyCode: "EUR"}},
+		{name: "german code", input: "0,002 EUR", locale: LocaleDeDE, want: &Money{Units: 0, Nanos: 2000000, CurrencyCode: "EUR"}},
+		{name: "french", input: "1 234,5 €", locale: LocaleFrFR, want: &Money{Units: 1234, Nanos: 500000000, CurrencyCode: "EUR"}},
+		{name: "english separators in german", input: "1,234.56 €", locale: LocaleDeDE, wantErr: true, errIs: ErrInvalidAmount},
+		{name: "no decimal separator", input: "USD 1", locale: Locale{Tag: "xx"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoneyLocale(tt.input, tt.locale)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_Format_ParseMoneyLocale_RoundTrip(t *testing.T) {
+	locales := []Locale{LocaleEnUS, LocaleEnGB, LocaleDeDE, LocaleFrFR}
+	amounts := []Money{
+		{Units: 0, Nanos: 1, CurrencyCode: "USD"},
+		{Units: 1234567, Nanos: 890000000, CurrencyCode: "EUR"},
+		{Units: -42, Nanos: -5, CurrencyCode: "GBP"},
+		{Units: 1500, CurrencyCode: "JPY"},
+		{Units: 7, Nanos: 123, CurrencyCode: "CHF"},
+	}
+
+	for _, locale := range locales {
+		for _, m := range amounts {
+			for _, code := range []bool{false, true} {
+				s, err := m.Format(FormatOptions{Locale: locale, Places: 9, Code: code})
+				assert.NoError(t, err)
+
+				got, err := ParseMoneyLocale(s, locale)
+				assert.NoError(t, err, s)
+				assert.Equal(t, &m, got, s)
+			}
+		}
+	}
+}
@@ -7,6 +7,9 @@ toolchain go1.24.0
 require (
 	github.com/awee-ai/go-tokenizer v0.0.0-20250713234627-e13d63d7f310
 	github.com/stretchr/testify v1.10.0
+	google.golang.org/genproto v0.0.0-20250603155806-513f23925822
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -16,5 +19,4 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.13.1 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,135 @@
+package aicost
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+var ErrRatesNotFound = errors.New("no conversion rates for the date")
+
+// RateFallback is what ConvertAt does when there are no rates for the requested date
+type RateFallback int
+
+const (
+	// FallbackPreviousBusinessDay uses the rates of the closest earlier weekday that has rates, at most a week back,
+	// e.g. the rates of Friday on a weekend or of the day before a holiday
+	FallbackPreviousBusinessDay RateFallback = iota
+	// FallbackError returns ErrRatesNotFound
+	FallbackError
+)
+
+// rateLookbackDays is how many days FallbackPreviousBusinessDay looks back
+const rateLookbackDays = 7
+
+func (f RateFallback) String() string {
+	switch f {
+	case FallbackPreviousBusinessDay:
+		return "previous-business-day"
+	case FallbackError:
+		return "error"
+	}
+
+	return fmt.Sprintf("RateFallback(%d)", int(f))
+}
+
+// RatesAt sets the rates against the base currency in effect on the date of day, replacing the rates of that date
+// only the year, month and day of day are used, in its location
+func (c *converter) RatesAt(day time.Time, rates map[string]float64) error {
+	if err := validateRates(rates); err != nil {
+		return fmt.Errorf("rates of %s: %w", day.Format(time.DateOnly), err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := day.Format(time.DateOnly)
+	if c.history == nil {
+		c.history = map[string]map[string]float64{}
+	}
+	c.history[key] = maps.Clone(rates)
+	delete(c.graphs, key)
+	c.datedGraphs = slices.DeleteFunc(c.datedGraphs, func(d string) bool { return d == key })
+
+	return nil
+}
+
+// Fallback sets what ConvertAt does for a date without rates, FallbackPreviousBusinessDay by default
+func (c *converter) Fallback(fallback RateFallback) error {
+	switch fallback {
+	case FallbackPreviousBusinessDay, FallbackError:
+		c.mu.Lock()
+		c.fallback = fallback
+		c.mu.Unlock()
+		return nil
+	}
+
+	return fmt.Errorf("unknown rate fallback: %s", fallback)
+}
+
+// ConvertAt converts an amount like Convert with the rates in effect on the date of at, in its location, see RatesAt
+// the pair rates are not dated, they are used with the rates of every date,
+// and a converter without dated rates converts with its current rates
+func (c *converter) ConvertAt(providedMoney Money, toCurrency string, at time.Time) (*Money, error) {
+	conversion, err := c.ConvertPathAt(providedMoney, toCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversion.Result, nil
+}
+
+// ConvertPathAt converts an amount like ConvertAt and returns the path it took and the date of the rates it used
+// the date is nil when no dated rates were needed, for the same currency or a converter without dated rates
+func (c *converter) ConvertPathAt(providedMoney Money, toCurrency string, at time.Time) (*Conversion, error) {
+	c.mu.RLock()
+	dated := len(c.history) > 0
+	c.mu.RUnlock()
+
+	// the same currency needs no rates
+	if !dated || providedMoney.CurrencyCode == toCurrency {
+		return c.ConvertPath(providedMoney, toCurrency)
+	}
+
+	day, err := c.ratesOn(at)
+	if err != nil {
+		return nil, err
+	}
+
+	conversion, err := c.convertPath(day.Format(time.DateOnly), providedMoney, toCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("rates of %s: %w", day.Format(time.DateOnly), err)
+	}
+	conversion.RatesDate = &day
+
+	return conversion, nil
+}
+
+// ratesOn returns the date of the rates in effect on the date of at, applying the fallback
+func (c *converter) ratesOn(at time.Time) (time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	if _, ok := c.history[day.Format(time.DateOnly)]; ok {
+		return day, nil
+	}
+
+	if c.fallback == FallbackPreviousBusinessDay {
+		for i := 1; i <= rateLookbackDays; i++ {
+			previous := day.AddDate(0, 0, -i)
+			if previous.Weekday() == time.Saturday || previous.Weekday() == time.Sunday {
+				continue
+			}
+			if _, ok := c.history[previous.Format(time.DateOnly)]; ok {
+				return previous, nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf("%w: %s or the %d days before", ErrRatesNotFound, day.Format(time.DateOnly), rateLookbackDays)
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s", ErrRatesNotFound, day.Format(time.DateOnly))
+}
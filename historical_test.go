@@ -0,0 +1,231 @@
+package aicost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Converter_ConvertAt(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	history := map[string]map[string]float64{
+		"2025-03-13": {"EUR": 0.91},
+		"2025-03-14": {"EUR": 0.92},
+		"2025-03-15": {"EUR": 0.5}, // a Saturday, never a business day
+		"2025-03-17": {"EUR": 0.93},
+	}
+
+	tests := []struct {
+		name     string
+		fallback RateFallback
+		at       time.Time
+		want     *Money
+		wantDate string
+		wantErr  bool
+		errIs    error
+	}{
+		{
+			name:     "rates of the date",
+			at:       date("2025-03-14").Add(15 * time.Hour),
+			want:     &Money{Units: 92, CurrencyCode: "EUR"},
+			wantDate: "2025-03-14",
+		},
+		{
+			name:     "rates of a weekend date",
+			at:       date("2025-03-15"),
+			want:     &Money{Units: 50, CurrencyCode: "EUR"},
+			wantDate: "2025-03-15",
+		},
+		{
+			name:     "sunday falls back to friday",
+			at:       date("2025-03-16"),
+			want:     &Money{Units: 92, CurrencyCode: "EUR"},
+			wantDate: "2025-03-14",
+		},
+		{
+			name:     "date in the location of at",
+			at:       time.Date(2025, 3, 17, 1, 0, 0, 0, time.FixedZone("CET", 3600)),
+			want:     &Money{Units: 93, CurrencyCode: "EUR"},
+			wantDate: "2025-03-17",
+		},
+		{
+			name:     "missing weekday falls back to the previous one",
+			at:       date("2025-03-19"),
+			want:     &Money{Units: 93, CurrencyCode: "EUR"},
+			wantDate: "2025-03-17",
+		},
+		{
+			name:    "nothing within a week",
+			at:      date("2025-03-25"),
+			wantErr: true,
+			errIs:   ErrRatesNotFound,
+		},
+		{
+			name:    "before the first rates",
+			at:      date("2025-03-01"),
+			wantErr: true,
+			errIs:   ErrRatesNotFound,
+		},
+		{
+			name:     "error fallback",
+			fallback: FallbackError,
+			at:       date("2025-03-16"),
+			wantErr:  true,
+			errIs:    ErrRatesNotFound,
+		},
+		{
+			name:     "error fallback with rates of the date",
+			fallback: FallbackError,
+			at:       date("2025-03-13"),
+			want:     &Money{Units: 91, CurrencyCode: "EUR"},
+			wantDate: "2025-03-13",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("USD", testRates)
+			for day, rates := range history {
+				assert.NoError(t, c.RatesAt(date(day), rates))
+			}
+			assert.NoError(t, c.Fallback(tt.fallback))
+
+			got, err := c.ConvertPathAt(Money{Units: 100, CurrencyCode: "USD"}, "EUR", tt.at)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Result)
+			if assert.NotNil(t, got.RatesDate) {
+				assert.Equal(t, tt.wantDate, got.RatesDate.Format(time.DateOnly))
+			}
+
+			converted, err := c.ConvertAt(Money{Units: 100, CurrencyCode: "USD"}, "EUR", tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, converted)
+		})
+	}
+}
+
+func Test_Converter_ConvertAt_KeepsCurrentRates(t *testing.T) {
+	c := NewConverter("USD", testRates)
+	assert.NoError(t, c.RatesAt(time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), map[string]float64{"EUR": 0.92}))
+
+	current, err := c.Convert(Money{Units: 100, CurrencyCode: "USD"}, "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 85, CurrencyCode: "EUR"}, current)
+
+	// GBP has no rate on that date, only today
+	_, err = c.ConvertAt(Money{Units: 100, CurrencyCode: "USD"}, "GBP", time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func Test_Converter_ConvertAt_WithoutRatesOfTheDate(t *testing.T) {
+	at := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	usd := Money{Units: 100, CurrencyCode: "USD"}
+
+	// without dated rates the current rates are used
+	c := NewConverter("USD", testRates)
+	got, err := c.ConvertPathAt(usd, "EUR", at)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 85, CurrencyCode: "EUR"}, got.Result)
+	assert.Nil(t, got.RatesDate)
+
+	// the same currency needs no rates of the date
+	assert.NoError(t, c.RatesAt(at.AddDate(0, -1, 0), map[string]float64{"EUR": 0.92}))
+	got, err = c.ConvertPathAt(usd, "USD", at)
+	assert.NoError(t, err)
+	assert.Equal(t, &usd, got.Result)
+	assert.Nil(t, got.RatesDate)
+
+	_, err = c.ConvertPathAt(usd, "EUR", at)
+	assert.ErrorIs(t, err, ErrRatesNotFound)
+}
+
+func Test_Converter_ConvertAt_Graphs(t *testing.T) {
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := 2 * maxDatedGraphs
+	usd := Money{Units: 100, CurrencyCode: "USD"}
+
+	c := NewConverter("USD", testRates)
+	for i := 0; i < days; i++ {
+		assert.NoError(t, c.RatesAt(first.AddDate(0, 0, i), map[string]float64{"EUR": 0.9}))
+	}
+	// the graphs are built on first use
+	assert.Empty(t, c.graphs)
+
+	for i := 0; i < days; i++ {
+		_, err := c.ConvertAt(usd, "EUR", first.AddDate(0, 0, i))
+		assert.NoError(t, err)
+	}
+	assert.Len(t, c.graphs, maxDatedGraphs)
+	assert.Len(t, c.datedGraphs, maxDatedGraphs)
+
+	// a dropped graph is built again
+	got, err := c.ConvertAt(usd, "EUR", first)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 90, CurrencyCode: "EUR"}, got)
+	assert.Len(t, c.graphs, maxDatedGraphs)
+
+	// replaced rates drop the graph of their date
+	assert.NoError(t, c.RatesAt(first, map[string]float64{"EUR": 0.8}))
+	assert.Len(t, c.datedGraphs, maxDatedGraphs-1)
+	got, err = c.ConvertAt(usd, "EUR", first)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 80, CurrencyCode: "EUR"}, got)
+}
+
+func Test_Converter_RatesAt(t *testing.T) {
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rates   map[string]float64
+		wantErr bool
+		errIs   error
+	}{
+		{name: "valid rates", rates: map[string]float64{"EUR": 0.92}},
+		{name: "empty rates", rates: map[string]float64{}, wantErr: true},
+		{name: "zero rate", rates: map[string]float64{"EUR": 0}, wantErr: true},
+		{name: "unknown currency", rates: map[string]float64{"XYZ": 1.5}, wantErr: true, errIs: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter("USD", nil)
+			err := c.RatesAt(day, tt.rates)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Nil(t, c.history)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rates, c.history["2025-03-14"])
+		})
+	}
+}
+
+func Test_Converter_Fallback(t *testing.T) {
+	c := NewConverter("USD", nil)
+	assert.NoError(t, c.Fallback(FallbackError))
+	assert.Equal(t, FallbackError, c.fallback)
+
+	assert.Error(t, c.Fallback(RateFallback(7)))
+	assert.Equal(t, FallbackError, c.fallback)
+}
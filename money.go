@@ -4,8 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"sort"
+	"strings"
 )
 
+var ErrMoneyOverflow = errors.New("money amount overflows int64 units")
+
 // Money represents a monetary value
 // can be:
 // cost per single token
@@ -22,30 +27,54 @@ type Money struct {
 }
 
 // NewMoney creates a new money object with validation
+// the currency must be ISO 4217 or registered with RegisterCurrency, see Validate
 func NewMoney(currency string, units int64, nanos int32) (*Money, error) {
+	m := &Money{
+		Nanos:        nanos,
+		CurrencyCode: currency,
+		Units:        units,
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Validate checks the invariants of m: nanos between -999,999,999 and 999,999,999,
+// units and nanos with the same sign, and a known currency
+func (m *Money) Validate() error {
 	// Validate that nanos is within range
-	if nanos < -999999999 || nanos > 999999999 {
-		return nil, errors.New("nanos must be between -999999999 and 999999999")
+	if m.Nanos < -999999999 || m.Nanos > 999999999 {
+		return errors.New("nanos must be between -999999999 and 999999999")
 	}
 
 	// Validate that units and nanos have the same sign
-	if (units < 0 && nanos > 0) || (units > 0 && nanos < 0) {
-		return nil, fmt.Errorf("units and nanos must have the same sign: units[%d], nanos[%d]", units, nanos)
+	if (m.Units < 0 && m.Nanos > 0) || (m.Units > 0 && m.Nanos < 0) {
+		return fmt.Errorf("units and nanos must have the same sign: units[%d], nanos[%d]", m.Units, m.Nanos)
 	}
 
-	if currency == "" {
-		return nil, errors.New("currency code cannot be empty")
+	if m.CurrencyCode == "" {
+		return errors.New("currency code cannot be empty")
 	}
 
-	return &Money{
-		Nanos:        nanos,
-		CurrencyCode: currency,
-		Units:        units,
-	}, nil
+	return validateCurrency(m.CurrencyCode)
+}
+
+// Normalize returns m with the whole units of nanos carried into units and the signs of units and nanos made equal,
+// e.g. 1 unit and -5 nanos becomes 0 units and 999,999,995 nanos
+// the currency is kept as it is, an amount that does not fit in Units returns ErrMoneyOverflow
+func (m *Money) Normalize() (*Money, error) {
+	units, nanos, err := splitNanos(m.totalNanos())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Money{Units: units, Nanos: nanos, CurrencyCode: m.CurrencyCode}, nil
 }
 
 // NewMoneyUnsafe creates a new money object without validation
-// Only use when you're certain the inputs are valid
+// Only use when you're certain the inputs are valid, see Validate and Normalize
 func NewMoneyUnsafe(currency string, units int64, nanos int32) *Money {
 	return &Money{
 		Nanos:        nanos,
@@ -55,66 +84,171 @@ func NewMoneyUnsafe(currency string, units int64, nanos int32) *Money {
 }
 
 // Add adds two Money objects together
+// the sum is exact, an amount that does not fit in Units returns ErrMoneyOverflow
+// the operands are normalized, an empty or unknown currency is rejected
 func (m *Money) Add(n *Money) (*Money, error) {
-	if m.CurrencyCode != n.CurrencyCode {
-		return nil, fmt.Errorf("currency codes do not match: %s != %s", m.CurrencyCode, n.CurrencyCode)
+	a, b, err := operands(m, n)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate total in nanos to avoid sign issues
-	totalNanos := int64(m.Units)*1e9 + int64(m.Nanos) + int64(n.Units)*1e9 + int64(n.Nanos)
-
-	// Convert back to units and nanos
-	units := totalNanos / 1e9
-	nanos := int32(totalNanos % 1e9)
-
-	return NewMoney(m.CurrencyCode, units, nanos)
+	return moneyFromNanos(m.CurrencyCode, a.Add(a, b))
 }
 
 // Times multiplies Money by an integer factor
+// the product is exact, an amount that does not fit in Units returns ErrMoneyOverflow
+// m is normalized, an empty or unknown currency is rejected
 func (m *Money) Times(n int64) (*Money, error) {
+	total, err := m.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	return moneyFromNanos(m.CurrencyCode, total.Mul(total, big.NewInt(n)))
+}
+
+// Sub subtracts n from m
+// the difference is exact, an amount that does not fit in Units returns ErrMoneyOverflow
+// the operands are normalized, an empty or unknown currency is rejected
+func (m *Money) Sub(n *Money) (*Money, error) {
+	a, b, err := operands(m, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return moneyFromNanos(m.CurrencyCode, a.Sub(a, b))
+}
+
+// Neg returns m with the opposite sign
+// m is normalized, an empty or unknown currency is rejected
+func (m *Money) Neg() (*Money, error) {
+	total, err := m.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	return moneyFromNanos(m.CurrencyCode, total.Neg(total))
+}
+
+// Abs returns the absolute value of m
+// m is normalized, an empty or unknown currency is rejected
+func (m *Money) Abs() (*Money, error) {
+	total, err := m.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	return moneyFromNanos(m.CurrencyCode, total.Abs(total))
+}
+
+// DivInt divides Money by an integer, the quotient is rounded to nanos, half away from zero
+// use Allocate to split an amount into parts that add up exactly
+// m is normalized, an empty or unknown currency is rejected
+func (m *Money) DivInt(n int64) (*Money, error) {
 	if n == 0 {
-		return NewMoney(m.CurrencyCode, 0, 0)
+		return nil, errors.New("division by zero")
 	}
 
-	// Check for overflow in units
-	if m.Units != 0 && n > math.MaxInt64/m.Units {
-		return nil, errors.New("integer overflow in units multiplication")
+	total, err := m.operand()
+	if err != nil {
+		return nil, err
 	}
+	quo := new(big.Rat).SetFrac(total, big.NewInt(n))
 
-	// Calculate total in nanos to handle sign correctly
-	totalNanos := int64(m.Units)*1e9*n + int64(m.Nanos)*n
+	return moneyFromNanos(m.CurrencyCode, roundHalfUp(quo))
+}
 
-	// Convert back to units and nanos
-	units := totalNanos / 1e9
-	nanos := int32(totalNanos % 1e9)
+// Ratio returns the exact ratio m / n
+// the operands are normalized, an empty or unknown currency is rejected
+func (m *Money) Ratio(n *Money) (*big.Rat, error) {
+	num, denom, err := operands(m, n)
+	if err != nil {
+		return nil, err
+	}
+	if denom.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
 
-	return NewMoney(m.CurrencyCode, units, nanos)
+	return new(big.Rat).SetFrac(num, denom), nil
 }
 
-// TimesFloat multiplies Money by a floating-point factor
-func (m *Money) TimesFloat(rate float64) (*Money, error) {
-	if rate == 0 {
-		money, err := NewMoney(m.CurrencyCode, 0, 0)
+// Allocate splits m into parts proportional to weights that add up exactly to m
+// every part gets its share rounded toward zero and the nanos left over go one by one
+// to the parts with the largest remainders, ties going to the first of them (largest remainder method)
+// m is normalized, an empty or unknown currency is rejected
+func (m *Money) Allocate(weights []int64) ([]*Money, error) {
+	if len(weights) == 0 {
+		return nil, errors.New("no weights to allocate to")
+	}
+	total, err := m.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := new(big.Int)
+	for i, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("weight %d must not be negative: %d", i, w)
+		}
+		sum.Add(sum, big.NewInt(w))
+	}
+	if sum.Sign() == 0 {
+		return nil, errors.New("weights must not all be zero")
+	}
+
+	sign := big.NewInt(int64(total.Sign()))
+	abs := new(big.Int).Abs(total)
+
+	shares := make([]*big.Int, len(weights))
+	remainders := make([]*big.Int, len(weights))
+	left := new(big.Int).Set(abs)
+	for i, w := range weights {
+		product := new(big.Int).Mul(abs, big.NewInt(w))
+		shares[i], remainders[i] = new(big.Int).QuoRem(product, sum, new(big.Int))
+		left.Sub(left, shares[i])
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	// left is smaller than the number of parts, every remainder is below sum
+	for i := int64(0); i < left.Int64(); i++ {
+		shares[order[i]].Add(shares[order[i]], big.NewInt(1))
+	}
+
+	parts := make([]*Money, len(weights))
+	for i, share := range shares {
+		part, err := moneyFromNanos(m.CurrencyCode, share.Mul(share, sign))
 		if err != nil {
-			return nil, fmt.Errorf("failed to create zero rate float money: %w", err)
+			return nil, fmt.Errorf("failed to create part %d: %w", i, err)
 		}
-		return money, nil
+		parts[i] = part
 	}
 
-	// calculate total in nanos to handle sign correctly
-	totalNanos := (float64(m.Units)*1e9 + float64(m.Nanos)) * rate
+	return parts, nil
+}
 
-	// check for overflow
-	if totalNanos > math.MaxInt64 || totalNanos < math.MinInt64 {
-		return nil, errors.New("overflow in float multiplication")
+// TimesFloat multiplies Money by a floating-point factor
+// the exact value of rate is used and the product is rounded once to nanos, half away from zero
+// m is normalized, an empty or unknown currency is rejected
+func (m *Money) TimesFloat(rate float64) (*Money, error) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return nil, fmt.Errorf("invalid float factor %v", rate)
 	}
 
-	roundedNanos := math.Round(totalNanos)
-	// convert back to units and nanos
-	units := int64(roundedNanos / 1e9)
-	nanos := int32(roundedNanos - float64(units*1e9))
+	nanos, err := m.operand()
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Rat).SetInt(nanos)
+	total.Mul(total, new(big.Rat).SetFloat64(rate))
 
-	money, err := NewMoney(m.CurrencyCode, units, nanos)
+	money, err := moneyFromNanos(m.CurrencyCode, roundHalfUp(total))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create money by multiplying by float: %w", err)
 	}
@@ -124,38 +258,164 @@ func (m *Money) TimesFloat(rate float64) (*Money, error) {
 
 // NewMoneyFromFloat converts a float64 cost to a Money struct.
 // it makes the creation of Money instances more human-readable.
+// the exact value of amount is rounded once to nanos, half away from zero
 func NewMoneyFromFloat(currencyCode string, amount float64) (*Money, error) {
-	// handle potential floating-point precision issues by working with a scaled integer
-	scaledAmount := amount * 1e9
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, fmt.Errorf("failed to create money from float: invalid amount %v", amount)
+	}
 
-	units := int64(scaledAmount / 1e9)
-	nanos := int32(scaledAmount - float64(units)*1e9)
+	total := new(big.Rat).SetFloat64(amount)
+	total.Mul(total, new(big.Rat).SetInt(nanosPerUnit))
 
-	money, err := NewMoney(currencyCode, units, nanos)
+	money, err := moneyFromNanos(currencyCode, roundHalfUp(total))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create money from float: %w", err)
 	}
 	return money, err
 }
 
+// NewMoneyFromString creates Money from an exact decimal amount, e.g. "0.000003" or "-12.5"
+// the amount has an optional sign, no group separators and at most 9 decimal places, it is never rounded
+func NewMoneyFromString(currencyCode string, amount string) (*Money, error) {
+	digits, negative := strings.CutPrefix(amount, "-")
+	if !negative {
+		digits = strings.TrimPrefix(amount, "+")
+	}
+
+	total, err := parseDecimal(digits, ".", "")
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidAmount, amount, err)
+	}
+	if negative {
+		total.Neg(total)
+	}
+
+	money, err := moneyFromNanos(currencyCode, total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create money from string: %w", err)
+	}
+
+	return money, nil
+}
+
+// NewMoneyFromRat creates Money from an exact rational amount of units
+// an amount that is not a whole number of nanos, e.g. 1/3, is rounded to nanos with mode
+func NewMoneyFromRat(currencyCode string, amount *big.Rat, mode RoundingMode) (*Money, error) {
+	nanos := new(big.Rat).Mul(amount, new(big.Rat).SetInt(nanosPerUnit))
+
+	total, err := roundRat(nanos, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	money, err := moneyFromNanos(currencyCode, total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create money from rat: %w", err)
+	}
+
+	return money, nil
+}
+
+// NewMoneyFromMicros creates Money from an amount in millionths of a unit, e.g. 3 micros is 0.000003
+func NewMoneyFromMicros(currencyCode string, micros int64) (*Money, error) {
+	total := new(big.Int).Mul(big.NewInt(micros), big.NewInt(1000))
+
+	return moneyFromNanos(currencyCode, total)
+}
+
 // MoneyToString converts Money to a string representation.
+// m is normalized, so 0 units and -5 nanos is written "USD -0.000000005"
 func MoneyToString(m Money) string {
-	return fmt.Sprintf("%s %d.%09d", m.CurrencyCode, m.Units, int(math.Abs(float64(m.Nanos))))
+	total := m.totalNanos()
+	units, nanos := new(big.Int).QuoRem(new(big.Int).Abs(total), nanosPerUnit, new(big.Int))
+
+	sign := ""
+	if total.Sign() < 0 {
+		sign = "-"
+	}
+
+	return fmt.Sprintf("%s %s%s.%09d", m.CurrencyCode, sign, units, nanos.Int64())
 }
 
-// MoneyToFloat64 converts Money to a float64 representation.
+// MoneyToFloat64 converts Money to the nearest float64.
+// m is normalized
 func MoneyToFloat64(m Money) float64 {
-	sign := 1.0
-	if m.Units < 0 || m.Nanos < 0 {
-		sign = -1.0
-	}
-	return sign * (math.Abs(float64(m.Units)) + math.Abs(float64(m.Nanos))/1e9)
+	f, _ := new(big.Rat).SetFrac(m.totalNanos(), nanosPerUnit).Float64()
+	return f
 }
 
 // MoneyToInt64 converts Money to an int64 representation with proper rounding.
+// halves are rounded away from zero, amounts beyond the int64 range saturate
+// m is normalized
 func MoneyToInt64(m Money) int64 {
-	// Calculate with proper rounding
-	totalNanos := float64(m.Units)*1e9 + float64(m.Nanos)
-	rounded := math.Round(totalNanos / 1e9)
-	return int64(rounded)
+	units := roundHalfUp(new(big.Rat).SetFrac(m.totalNanos(), nanosPerUnit))
+	switch {
+	case units.IsInt64():
+		return units.Int64()
+	case units.Sign() > 0:
+		return math.MaxInt64
+	}
+
+	return math.MinInt64
+}
+
+// nanosPerUnit is the number of nanos in a unit
+var nanosPerUnit = big.NewInt(1e9)
+
+// totalNanos returns the whole amount in nanos, it cannot overflow
+// it is the value of m whatever the range and signs of its units and nanos
+func (m *Money) totalNanos() *big.Int {
+	total := new(big.Int).Mul(big.NewInt(m.Units), nanosPerUnit)
+	return total.Add(total, big.NewInt(int64(m.Nanos)))
+}
+
+// operand returns the normalized amount of m in nanos, rejecting an empty or unknown currency
+func (m *Money) operand() (*big.Int, error) {
+	if m.CurrencyCode == "" {
+		return nil, errors.New("currency code cannot be empty")
+	}
+	if err := validateCurrency(m.CurrencyCode); err != nil {
+		return nil, err
+	}
+
+	return m.totalNanos(), nil
+}
+
+// operands returns the normalized amounts of m and n, which must have the same valid currency
+func operands(m, n *Money) (*big.Int, *big.Int, error) {
+	if err := sameCurrency(m, n); err != nil {
+		return nil, nil, err
+	}
+	a, err := m.operand()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, n.totalNanos(), nil
+}
+
+// moneyFromNanos splits an amount of nanos into units and nanos with the same sign
+func moneyFromNanos(currency string, total *big.Int) (*Money, error) {
+	units, nanos, err := splitNanos(total)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMoney(currency, units, nanos)
+}
+
+// splitNanos splits an amount of nanos into units and nanos with the same sign
+func splitNanos(total *big.Int) (int64, int32, error) {
+	units, nanos := new(big.Int).QuoRem(total, nanosPerUnit, new(big.Int))
+	if !units.IsInt64() {
+		return 0, 0, fmt.Errorf("%s nanos: %w", total, ErrMoneyOverflow)
+	}
+
+	return units.Int64(), int32(nanos.Int64()), nil
+}
+
+// roundHalfUp rounds r to the nearest integer, halves away from zero
+func roundHalfUp(r *big.Rat) *big.Int {
+	rounded, _ := roundRat(r, RoundHalfUp)
+	return rounded
 }
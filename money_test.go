@@ -1,7 +1,15 @@
 package aicost
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strconv"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -75,6 +83,22 @@ func Test_NewMoney(t *testing.T) {
 			want:     nil,
 			wantErr:  true,
 		},
+		{
+			name:     "unknown currency",
+			currency: "XYZ",
+			units:    1,
+			nanos:    0,
+			want:     nil,
+			wantErr:  true,
+		},
+		{
+			name:     "lower case currency",
+			currency: "usd",
+			units:    1,
+			nanos:    0,
+			want:     nil,
+			wantErr:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -459,6 +483,24 @@ func Test_MoneyToString(t *testing.T) {
 			},
 			want: "JPY 0.000000000",
 		},
+		{
+			name: "negative nanos only",
+			money: Money{
+				Units:        0,
+				Nanos:        -500000000,
+				CurrencyCode: "USD",
+			},
+			want: "USD -0.500000000",
+		},
+		{
+			name: "mixed signs are normalized",
+			money: Money{
+				Units:        1,
+				Nanos:        -5,
+				CurrencyCode: "USD",
+			},
+			want: "USD 0.999999995",
+		},
 	}
 
 	for _, tt := range tests {
@@ -572,6 +614,24 @@ func Test_MoneyToInt64(t *testing.T) {
 			},
 			want: 0,
 		},
+		{
+			name: "nanos beyond a unit are normalized",
+			money: Money{
+				Units:        1,
+				Nanos:        2000000000,
+				CurrencyCode: "USD",
+			},
+			want: 3,
+		},
+		{
+			name: "saturates",
+			money: Money{
+				Units:        math.MaxInt64,
+				Nanos:        999999999,
+				CurrencyCode: "USD",
+			},
+			want: math.MaxInt64,
+		},
 	}
 
 	for _, tt := range tests {
@@ -581,3 +641,746 @@ func Test_MoneyToInt64(t *testing.T) {
 		})
 	}
 }
+
+// quickMoney generates valid Money values for property tests, biased towards the int64 limits
+type quickMoney struct {
+	Money
+}
+
+func (quickMoney) Generate(r *rand.Rand, _ int) reflect.Value {
+	var units int64
+	switch r.Intn(4) {
+	case 0:
+		units = r.Int63n(1000)
+	case 1:
+		units = r.Int63()
+	case 2:
+		units = math.MaxInt64 - r.Int63n(1000)
+	default:
+		units = r.Int63n(1 << 40)
+	}
+	nanos := int32(r.Int63n(1e9))
+	if r.Intn(2) == 0 {
+		units, nanos = -units, -nanos
+	}
+
+	return reflect.ValueOf(quickMoney{Money{Units: units, Nanos: nanos, CurrencyCode: "USD"}})
+}
+
+// exactNanos is the reference amount of a Money in nanos, computed independently of the package helpers
+func exactNanos(m Money) *big.Int {
+	total := new(big.Int).Mul(big.NewInt(m.Units), big.NewInt(1000000000))
+	return total.Add(total, big.NewInt(int64(m.Nanos)))
+}
+
+// fitsMoney reports whether an amount of nanos can be represented by Money
+func fitsMoney(total *big.Int) bool {
+	units := new(big.Int).Quo(total, big.NewInt(1000000000))
+	return units.IsInt64()
+}
+
+func Test_Money_Add_Property(t *testing.T) {
+	exact := func(a, b quickMoney) bool {
+		want := new(big.Int).Add(exactNanos(a.Money), exactNanos(b.Money))
+		got, err := a.Add(&b.Money)
+		if !fitsMoney(want) {
+			return errors.Is(err, ErrMoneyOverflow) && got == nil
+		}
+		return err == nil && exactNanos(*got).Cmp(want) == 0
+	}
+	assert.NoError(t, quick.Check(exact, nil))
+
+	commutative := func(a, b quickMoney) bool {
+		ab, errAB := a.Add(&b.Money)
+		ba, errBA := b.Add(&a.Money)
+		return (errAB == nil) == (errBA == nil) && reflect.DeepEqual(ab, ba)
+	}
+	assert.NoError(t, quick.Check(commutative, nil))
+}
+
+func Test_Money_Times_Property(t *testing.T) {
+	exact := func(a quickMoney, n int64) bool {
+		want := new(big.Int).Mul(exactNanos(a.Money), big.NewInt(n))
+		got, err := a.Times(n)
+		if !fitsMoney(want) {
+			return errors.Is(err, ErrMoneyOverflow) && got == nil
+		}
+		return err == nil && exactNanos(*got).Cmp(want) == 0
+	}
+	assert.NoError(t, quick.Check(exact, nil))
+
+	// nanos-only products used to wrap around in int64 before the units check caught them
+	small := func(nanos uint32, n int64) bool {
+		m := Money{Units: 0, Nanos: int32(nanos % 1e9), CurrencyCode: "USD"}
+		want := new(big.Int).Mul(exactNanos(m), big.NewInt(n))
+		got, err := m.Times(n)
+		if !fitsMoney(want) {
+			return errors.Is(err, ErrMoneyOverflow)
+		}
+		return err == nil && exactNanos(*got).Cmp(want) == 0
+	}
+	assert.NoError(t, quick.Check(small, nil))
+}
+
+func Test_Money_TimesFloat_Property(t *testing.T) {
+	// an integral float factor is exact and matches Times
+	integral := func(a quickMoney, n int32) bool {
+		want, wantErr := a.Times(int64(n))
+		got, err := a.TimesFloat(float64(n))
+		if wantErr != nil {
+			return errors.Is(err, ErrMoneyOverflow)
+		}
+		return err == nil && reflect.DeepEqual(want, got)
+	}
+	assert.NoError(t, quick.Check(integral, nil))
+
+	// the product is within half a nano of the exact value of the float factor
+	rounded := func(a quickMoney, rate float64) bool {
+		exact := new(big.Rat).SetInt(exactNanos(a.Money))
+		exact.Mul(exact, new(big.Rat).SetFloat64(rate))
+
+		got, err := a.TimesFloat(rate)
+		if err != nil {
+			return errors.Is(err, ErrMoneyOverflow)
+		}
+
+		diff := new(big.Rat).Sub(new(big.Rat).SetInt(exactNanos(*got)), exact)
+		return diff.Abs(diff).Cmp(big.NewRat(1, 2)) <= 0
+	}
+	assert.NoError(t, quick.Check(rounded, nil))
+}
+
+func Test_NewMoneyFromFloat_Property(t *testing.T) {
+	// values with at most nine decimals survive the round trip through float64 exactly
+	roundTrip := func(units int32, nanos uint32) bool {
+		m := Money{Units: int64(units % 1000000), Nanos: int32(nanos % 1e9), CurrencyCode: "USD"}
+		if m.Units < 0 {
+			m.Nanos = -m.Nanos
+		}
+
+		got, err := NewMoneyFromFloat("USD", MoneyToFloat64(m))
+		return err == nil && reflect.DeepEqual(&m, got)
+	}
+	assert.NoError(t, quick.Check(roundTrip, nil))
+
+	_, err := NewMoneyFromFloat("USD", 1e30)
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+
+	_, err = NewMoneyFromFloat("USD", math.NaN())
+	assert.Error(t, err)
+}
+
+func Test_Money_Precision(t *testing.T) {
+	// $0.000003 per token used to come out as 2999 nanos
+	price, err := NewMoneyFromFloat("USD", 0.000003)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"}, price)
+
+	// units past 9.2e9 overflowed the int64 nanos total
+	big1 := Money{Units: 10000000000, Nanos: 500000000, CurrencyCode: "USD"}
+	sum, err := big1.Add(&big1)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 20000000001, Nanos: 0, CurrencyCode: "USD"}, sum)
+
+	// MoneyToInt64 no longer goes through float64
+	assert.Equal(t, int64(9007199254740993), MoneyToInt64(Money{Units: 9007199254740993, Nanos: 1, CurrencyCode: "USD"}))
+	assert.Equal(t, int64(math.MaxInt64), MoneyToInt64(Money{Units: math.MaxInt64, Nanos: 900000000, CurrencyCode: "USD"}))
+}
+
+func Test_Money_Sub(t *testing.T) {
+	tests := []struct {
+		name    string
+		m1      *Money
+		m2      *Money
+		want    *Money
+		wantErr bool
+	}{
+		{
+			name: "valid subtraction",
+			m1:   &Money{Units: 5, Nanos: 200000000, CurrencyCode: "USD"},
+			m2:   &Money{Units: 3, Nanos: 400000000, CurrencyCode: "USD"},
+			want: &Money{Units: 1, Nanos: 800000000, CurrencyCode: "USD"},
+		},
+		{
+			name: "negative result",
+			m1:   &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			m2:   &Money{Units: 1, Nanos: 500000000, CurrencyCode: "USD"},
+			want: &Money{Units: 0, Nanos: -500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:    "overflow",
+			m1:      &Money{Units: math.MinInt64, Nanos: 0, CurrencyCode: "USD"},
+			m2:      &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			wantErr: true,
+		},
+		{
+			name:    "currency mismatch",
+			m1:      &Money{Units: 5, Nanos: 0, CurrencyCode: "USD"},
+			m2:      &Money{Units: 3, Nanos: 0, CurrencyCode: "EUR"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m1.Sub(tt.m2)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_NegAbs(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   *Money
+		wantNeg *Money
+		wantAbs *Money
+		wantErr bool
+	}{
+		{
+			name:    "positive",
+			money:   &Money{Units: 2, Nanos: 500000000, CurrencyCode: "USD"},
+			wantNeg: &Money{Units: -2, Nanos: -500000000, CurrencyCode: "USD"},
+			wantAbs: &Money{Units: 2, Nanos: 500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:    "negative nanos only",
+			money:   &Money{Units: 0, Nanos: -5, CurrencyCode: "USD"},
+			wantNeg: &Money{Units: 0, Nanos: 5, CurrencyCode: "USD"},
+			wantAbs: &Money{Units: 0, Nanos: 5, CurrencyCode: "USD"},
+		},
+		{
+			name:    "zero",
+			money:   &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+			wantNeg: &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+			wantAbs: &Money{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+		},
+		{
+			name:    "min int64 overflows",
+			money:   &Money{Units: math.MinInt64, Nanos: 0, CurrencyCode: "USD"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neg, err := tt.money.Neg()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrMoneyOverflow)
+				_, err = tt.money.Abs()
+				assert.ErrorIs(t, err, ErrMoneyOverflow)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNeg, neg)
+
+			abs, err := tt.money.Abs()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAbs, abs)
+		})
+	}
+}
+
+func Test_Money_DivInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   *Money
+		divisor int64
+		want    *Money
+		wantErr bool
+	}{
+		{
+			name:    "exact division",
+			money:   &Money{Units: 10, Nanos: 0, CurrencyCode: "USD"},
+			divisor: 4,
+			want:    &Money{Units: 2, Nanos: 500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:    "rounded division",
+			money:   &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			divisor: 3,
+			want:    &Money{Units: 0, Nanos: 333333333, CurrencyCode: "USD"},
+		},
+		{
+			name:    "half rounds away from zero",
+			money:   &Money{Units: 0, Nanos: -5, CurrencyCode: "USD"},
+			divisor: 2,
+			want:    &Money{Units: 0, Nanos: -3, CurrencyCode: "USD"},
+		},
+		{
+			name:    "negative divisor",
+			money:   &Money{Units: 9, Nanos: 0, CurrencyCode: "USD"},
+			divisor: -3,
+			want:    &Money{Units: -3, Nanos: 0, CurrencyCode: "USD"},
+		},
+		{
+			name:    "division by zero",
+			money:   &Money{Units: 9, Nanos: 0, CurrencyCode: "USD"},
+			divisor: 0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.DivInt(tt.divisor)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_Ratio(t *testing.T) {
+	a := &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"}
+	b := &Money{Units: 3, Nanos: 0, CurrencyCode: "USD"}
+
+	got, err := a.Ratio(b)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewRat(1, 3), got)
+
+	_, err = a.Ratio(&Money{Units: 0, Nanos: 0, CurrencyCode: "USD"})
+	assert.Error(t, err)
+
+	_, err = a.Ratio(&Money{Units: 1, Nanos: 0, CurrencyCode: "EUR"})
+	assert.Error(t, err)
+}
+
+func Test_Money_Allocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   *Money
+		weights []int64
+		want    []*Money
+		wantErr bool
+	}{
+		{
+			name:    "even split with a leftover nano",
+			money:   &Money{Units: 0, Nanos: 100, CurrencyCode: "USD"},
+			weights: []int64{1, 1, 1},
+			want: []*Money{
+				{Units: 0, Nanos: 34, CurrencyCode: "USD"},
+				{Units: 0, Nanos: 33, CurrencyCode: "USD"},
+				{Units: 0, Nanos: 33, CurrencyCode: "USD"},
+			},
+		},
+		{
+			name:    "largest remainders get the leftover",
+			money:   &Money{Units: 0, Nanos: 10, CurrencyCode: "USD"},
+			weights: []int64{1, 2, 4},
+			want: []*Money{
+				{Units: 0, Nanos: 1, CurrencyCode: "USD"},
+				{Units: 0, Nanos: 3, CurrencyCode: "USD"},
+				{Units: 0, Nanos: 6, CurrencyCode: "USD"},
+			},
+		},
+		{
+			name:    "negative amount",
+			money:   &Money{Units: -1, Nanos: 0, CurrencyCode: "USD"},
+			weights: []int64{1, 1, 1},
+			want: []*Money{
+				{Units: 0, Nanos: -333333334, CurrencyCode: "USD"},
+				{Units: 0, Nanos: -333333333, CurrencyCode: "USD"},
+				{Units: 0, Nanos: -333333333, CurrencyCode: "USD"},
+			},
+		},
+		{
+			name:    "zero weight gets nothing",
+			money:   &Money{Units: 0, Nanos: 7, CurrencyCode: "USD"},
+			weights: []int64{0, 1, 1},
+			want: []*Money{
+				{Units: 0, Nanos: 0, CurrencyCode: "USD"},
+				{Units: 0, Nanos: 4, CurrencyCode: "USD"},
+				{Units: 0, Nanos: 3, CurrencyCode: "USD"},
+			},
+		},
+		{
+			name:    "no weights",
+			money:   &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			weights: nil,
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			money:   &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			weights: []int64{1, -1},
+			wantErr: true,
+		},
+		{
+			name:    "all zero weights",
+			money:   &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			weights: []int64{0, 0},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Allocate(tt.weights)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_Allocate_Property(t *testing.T) {
+	sumsExactly := func(a quickMoney, weights []uint16) bool {
+		ws := make([]int64, 0, len(weights))
+		var total int64
+		for _, w := range weights {
+			ws = append(ws, int64(w))
+			total += int64(w)
+		}
+		if total == 0 {
+			return true
+		}
+
+		parts, err := a.Allocate(ws)
+		if err != nil {
+			return false
+		}
+
+		sum := new(big.Int)
+		for _, p := range parts {
+			sum.Add(sum, exactNanos(*p))
+		}
+		return sum.Cmp(exactNanos(a.Money)) == 0
+	}
+	assert.NoError(t, quick.Check(sumsExactly, nil))
+}
+
+func Test_Money_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		wantErr bool
+	}{
+		{name: "valid", money: Money{Units: 1, Nanos: 5, CurrencyCode: "USD"}},
+		{name: "valid negative", money: Money{Units: -1, Nanos: -5, CurrencyCode: "USD"}},
+		{name: "valid nanos only", money: Money{Units: 0, Nanos: -5, CurrencyCode: "USD"}},
+		{name: "mixed signs", money: Money{Units: 1, Nanos: -5, CurrencyCode: "USD"}, wantErr: true},
+		{name: "nanos above range", money: Money{Units: 0, Nanos: 2000000000, CurrencyCode: "USD"}, wantErr: true},
+		{name: "nanos below range", money: Money{Units: 0, Nanos: -1000000000, CurrencyCode: "USD"}, wantErr: true},
+		{name: "empty currency", money: Money{Units: 1}, wantErr: true},
+		{name: "unknown currency", money: Money{Units: 1, CurrencyCode: "XYZ"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.money.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_Money_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		want    *Money
+		wantErr error
+	}{
+		{
+			name:  "already normalized",
+			money: Money{Units: 1, Nanos: 5, CurrencyCode: "USD"},
+			want:  &Money{Units: 1, Nanos: 5, CurrencyCode: "USD"},
+		},
+		{
+			name:  "negative nanos with positive units",
+			money: Money{Units: 1, Nanos: -5, CurrencyCode: "USD"},
+			want:  &Money{Units: 0, Nanos: 999999995, CurrencyCode: "USD"},
+		},
+		{
+			name:  "positive nanos with negative units",
+			money: Money{Units: -2, Nanos: 500000000, CurrencyCode: "USD"},
+			want:  &Money{Units: -1, Nanos: -500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:  "nanos carried into units",
+			money: Money{Units: 1, Nanos: 2000000000, CurrencyCode: "USD"},
+			want:  &Money{Units: 3, Nanos: 0, CurrencyCode: "USD"},
+		},
+		{
+			name:  "negative nanos carried into units",
+			money: Money{Units: 0, Nanos: -1500000000, CurrencyCode: "USD"},
+			want:  &Money{Units: -1, Nanos: -500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:  "currency is kept",
+			money: Money{Units: 0, Nanos: 1000000000},
+			want:  &Money{Units: 1, Nanos: 0},
+		},
+		{
+			name:    "overflow",
+			money:   Money{Units: math.MaxInt64, Nanos: 1000000000, CurrencyCode: "USD"},
+			wantErr: ErrMoneyOverflow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Normalize()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_Normalize_Property(t *testing.T) {
+	// any units and nanos normalize to a valid amount of the same value
+	normalized := func(units int64, nanos int32) bool {
+		m := Money{Units: units, Nanos: nanos, CurrencyCode: "USD"}
+		got, err := m.Normalize()
+		if !fitsMoney(exactNanos(m)) {
+			return errors.Is(err, ErrMoneyOverflow)
+		}
+		return err == nil && got.Validate() == nil && exactNanos(*got).Cmp(exactNanos(m)) == 0
+	}
+	assert.NoError(t, quick.Check(normalized, nil))
+}
+
+func Test_Money_Arithmetic_Unnormalized(t *testing.T) {
+	// 0.999999995 written with mixed signs, and 3 written with nanos beyond a unit
+	mixed := &Money{Units: 1, Nanos: -5, CurrencyCode: "USD"}
+	carried := &Money{Units: 1, Nanos: 2000000000, CurrencyCode: "USD"}
+
+	sum, err := mixed.Add(carried)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 3, Nanos: 999999995, CurrencyCode: "USD"}, sum)
+
+	diff, err := mixed.Sub(carried)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: -2, Nanos: -5, CurrencyCode: "USD"}, diff)
+
+	product, err := mixed.Times(2)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 1, Nanos: 999999990, CurrencyCode: "USD"}, product)
+
+	neg, err := carried.Neg()
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: -3, Nanos: 0, CurrencyCode: "USD"}, neg)
+
+	quo, err := carried.DivInt(3)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"}, quo)
+
+	rounded, err := mixed.Round(2, RoundHalfEven)
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"}, rounded)
+
+	c, err := carried.Cmp(&Money{Units: 3, CurrencyCode: "USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, c)
+
+	// an invalid currency is rejected by every entry point
+	empty := &Money{Units: 1}
+	unknown := &Money{Units: 1, CurrencyCode: "XYZ"}
+	for _, m := range []*Money{empty, unknown} {
+		_, err = m.Add(m)
+		assert.Error(t, err)
+		_, err = m.Sub(m)
+		assert.Error(t, err)
+		_, err = m.Times(2)
+		assert.Error(t, err)
+		_, err = m.TimesFloat(2)
+		assert.Error(t, err)
+		_, err = m.Neg()
+		assert.Error(t, err)
+		_, err = m.Abs()
+		assert.Error(t, err)
+		_, err = m.DivInt(2)
+		assert.Error(t, err)
+		_, err = m.Ratio(m)
+		assert.Error(t, err)
+		_, err = m.Allocate([]int64{1, 1})
+		assert.Error(t, err)
+		_, err = m.Round(2, RoundHalfEven)
+		assert.Error(t, err)
+		_, err = m.Cmp(m)
+		assert.Error(t, err)
+	}
+}
+
+func Test_NewMoneyFromString(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		want    *Money
+		wantErr bool
+	}{
+		{name: "per token price", amount: "0.000003", want: &Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"}},
+		{name: "whole units", amount: "12", want: &Money{Units: 12, Nanos: 0, CurrencyCode: "USD"}},
+		{name: "negative", amount: "-12.5", want: &Money{Units: -12, Nanos: -500000000, CurrencyCode: "USD"}},
+		{name: "explicit plus", amount: "+0.5", want: &Money{Units: 0, Nanos: 500000000, CurrencyCode: "USD"}},
+		{name: "one nano", amount: "0.000000001", want: &Money{Units: 0, Nanos: 1, CurrencyCode: "USD"}},
+		{name: "beyond float64 precision", amount: "100000000.000000003", want: &Money{Units: 100000000, Nanos: 3, CurrencyCode: "USD"}},
+		{name: "largest", amount: "9223372036854775807.999999999", want: &Money{Units: math.MaxInt64, Nanos: 999999999, CurrencyCode: "USD"}},
+		{name: "too many decimals", amount: "0.0000000001", wantErr: true},
+		{name: "overflow", amount: "9223372036854775808", wantErr: true},
+		{name: "empty", amount: "", wantErr: true},
+		{name: "missing units", amount: ".5", wantErr: true},
+		{name: "exponent", amount: "3e-6", wantErr: true},
+		{name: "group separator", amount: "1,000", wantErr: true},
+		{name: "double sign", amount: "--1", wantErr: true},
+		{name: "spaces", amount: " 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMoneyFromString("USD", tt.amount)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := NewMoneyFromString("XYZ", "1")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func Test_NewMoneyFromRat(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  *big.Rat
+		mode    RoundingMode
+		want    *Money
+		wantErr bool
+	}{
+		{name: "exact", amount: big.NewRat(3, 1000000), want: &Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"}},
+		{name: "third half even", amount: big.NewRat(1, 3), mode: RoundHalfEven, want: &Money{Units: 0, Nanos: 333333333, CurrencyCode: "USD"}},
+		{name: "two thirds down", amount: big.NewRat(2, 3), mode: RoundDown, want: &Money{Units: 0, Nanos: 666666666, CurrencyCode: "USD"}},
+		{name: "negative ceiling", amount: big.NewRat(-5, 3), mode: RoundCeiling, want: &Money{Units: -1, Nanos: -666666666, CurrencyCode: "USD"}},
+		{name: "half a nano half up", amount: big.NewRat(1, 2000000000), mode: RoundHalfUp, want: &Money{Units: 0, Nanos: 1, CurrencyCode: "USD"}},
+		{name: "overflow", amount: new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 64)), wantErr: true},
+		{name: "unknown mode", amount: big.NewRat(1, 3), mode: RoundingMode(42), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMoneyFromRat("USD", tt.amount, tt.mode)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_NewMoneyFromMicros(t *testing.T) {
+	tests := []struct {
+		name   string
+		micros int64
+		want   *Money
+	}{
+		{name: "per token price", micros: 3, want: &Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"}},
+		{name: "negative", micros: -1500000, want: &Money{Units: -1, Nanos: -500000000, CurrencyCode: "USD"}},
+		{name: "largest", micros: math.MaxInt64, want: &Money{Units: 9223372036854, Nanos: 775807000, CurrencyCode: "USD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMoneyFromMicros("USD", tt.micros)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_NewMoneyFromString_FloatPath(t *testing.T) {
+	// scaling the float by 1e9 and truncating, as prices used to be built, loses a nano
+	price := 0.00000003
+	assert.Equal(t, int64(29), int64(price*1e9))
+	exact, err := NewMoneyFromString("USD", "0.00000003")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30), exact.Nanos)
+
+	// beyond 2^53 nanos the float cannot hold the amount, whatever the rounding
+	for _, amount := range []string{"12345678.000000001", "100000000.000000003"} {
+		exact, err := NewMoneyFromString("USD", amount)
+		assert.NoError(t, err)
+
+		f, err := strconv.ParseFloat(amount, 64)
+		assert.NoError(t, err)
+		fromFloat, err := NewMoneyFromFloat("USD", f)
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, exact, fromFloat, amount)
+	}
+}
+
+func Fuzz_NewMoneyFromString(f *testing.F) {
+	f.Add(int64(0), uint32(3000))
+	f.Add(int64(0), uint32(30))
+	f.Add(int64(12345678), uint32(1))
+	f.Add(int64(-100000000), uint32(3))
+	f.Add(int64(math.MaxInt64), uint32(999999999))
+
+	f.Fuzz(func(t *testing.T, units int64, nanos uint32) {
+		nanos %= 1000000000
+		sign := ""
+		if units < 0 {
+			sign = "-"
+		}
+		abs := new(big.Int).Abs(big.NewInt(units))
+		amount := fmt.Sprintf("%s%s.%09d", sign, abs, nanos)
+
+		// the string path is exact
+		want := new(big.Int).Mul(big.NewInt(units), big.NewInt(1000000000))
+		if units < 0 {
+			want.Sub(want, big.NewInt(int64(nanos)))
+		} else {
+			want.Add(want, big.NewInt(int64(nanos)))
+		}
+		got, err := NewMoneyFromString("USD", amount)
+		if !assert.NoError(t, err, amount) {
+			return
+		}
+		assert.Equal(t, 0, exactNanos(*got).Cmp(want), amount)
+
+		// the float path is off by up to half the float spacing at that magnitude
+		parsed, err := strconv.ParseFloat(amount, 64)
+		assert.NoError(t, err)
+		fromFloat, err := NewMoneyFromFloat("USD", parsed)
+		if err != nil {
+			// the float rounded past the largest amount
+			assert.ErrorIs(t, err, ErrMoneyOverflow, amount)
+			return
+		}
+		spacing := new(big.Rat).SetFloat64(math.Nextafter(math.Abs(parsed), math.Inf(1)) - math.Abs(parsed))
+		bound := new(big.Rat).Mul(spacing, new(big.Rat).SetInt64(1000000000))
+		bound.Quo(bound, big.NewRat(2, 1)).Add(bound, big.NewRat(1, 2))
+		diff := new(big.Int).Sub(exactNanos(*fromFloat), want)
+		assert.True(t, new(big.Rat).SetInt(diff.Abs(diff)).Cmp(bound) <= 0, "%s: float path %s", amount, MoneyToString(*fromFloat))
+	})
+}
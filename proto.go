@@ -0,0 +1,36 @@
+package aicost
+
+import (
+	"errors"
+	"fmt"
+
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+)
+
+// ToProto converts m to a google.type.Money
+// m is validated like NewMoney does, the protobuf type requires the same sign and nanos rules
+func (m *Money) ToProto() (*moneypb.Money, error) {
+	if _, err := NewMoney(m.CurrencyCode, m.Units, m.Nanos); err != nil {
+		return nil, fmt.Errorf("invalid money for google.type.Money: %w", err)
+	}
+
+	return &moneypb.Money{
+		CurrencyCode: m.CurrencyCode,
+		Units:        m.Units,
+		Nanos:        m.Nanos,
+	}, nil
+}
+
+// MoneyFromProto converts a google.type.Money to Money, validating it with NewMoney
+func MoneyFromProto(p *moneypb.Money) (*Money, error) {
+	if p == nil {
+		return nil, errors.New("google.type.Money is nil")
+	}
+
+	m, err := NewMoney(p.GetCurrencyCode(), p.GetUnits(), p.GetNanos())
+	if err != nil {
+		return nil, fmt.Errorf("invalid google.type.Money: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,119 @@
+package aicost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	moneypb "google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_Money_ToProto(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		want    *moneypb.Money
+		wantErr bool
+	}{
+		{
+			name:  "positive",
+			money: Money{Units: 1, Nanos: 750000000, CurrencyCode: "USD"},
+			want:  &moneypb.Money{Units: 1, Nanos: 750000000, CurrencyCode: "USD"},
+		},
+		{
+			name:  "negative",
+			money: Money{Units: -1, Nanos: -750000000, CurrencyCode: "EUR"},
+			want:  &moneypb.Money{Units: -1, Nanos: -750000000, CurrencyCode: "EUR"},
+		},
+		{
+			name:  "negative nanos only",
+			money: Money{Units: 0, Nanos: -5, CurrencyCode: "USD"},
+			want:  &moneypb.Money{Units: 0, Nanos: -5, CurrencyCode: "USD"},
+		},
+		{
+			name:    "mixed signs",
+			money:   Money{Units: 1, Nanos: -5, CurrencyCode: "USD"},
+			wantErr: true,
+		},
+		{
+			name:    "nanos out of range",
+			money:   Money{Units: 0, Nanos: 1000000000, CurrencyCode: "USD"},
+			wantErr: true,
+		},
+		{
+			name:    "empty currency",
+			money:   Money{Units: 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.ToProto()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, proto.Equal(tt.want, got), "got %v", got)
+		})
+	}
+}
+
+func Test_MoneyFromProto(t *testing.T) {
+	tests := []struct {
+		name    string
+		proto   *moneypb.Money
+		want    *Money
+		wantErr bool
+	}{
+		{
+			name:  "positive",
+			proto: &moneypb.Money{Units: 12, Nanos: 500000000, CurrencyCode: "USD"},
+			want:  &Money{Units: 12, Nanos: 500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:  "negative",
+			proto: &moneypb.Money{Units: -12, Nanos: -500000000, CurrencyCode: "USD"},
+			want:  &Money{Units: -12, Nanos: -500000000, CurrencyCode: "USD"},
+		},
+		{
+			name:    "mixed signs",
+			proto:   &moneypb.Money{Units: -12, Nanos: 500000000, CurrencyCode: "USD"},
+			wantErr: true,
+		},
+		{
+			name:    "nanos out of range",
+			proto:   &moneypb.Money{Units: 0, Nanos: -1000000000, CurrencyCode: "USD"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown currency",
+			proto:   &moneypb.Money{Units: 1, CurrencyCode: "XYZ"},
+			wantErr: true,
+		},
+		{
+			name:    "nil",
+			proto:   nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MoneyFromProto(tt.proto)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			back, err := got.ToProto()
+			assert.NoError(t, err)
+			assert.True(t, proto.Equal(tt.proto, back))
+		})
+	}
+}
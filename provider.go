@@ -0,0 +1,158 @@
+package aicost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxRatesSize is the largest rates document the providers read
+const maxRatesSize = 10 << 20
+
+// RateProvider fetches the conversion rates against a base currency, see RefreshingConverter
+type RateProvider interface {
+	// Fetch returns the rates and the time they were published, the zero time when the source does not say
+	Fetch(ctx context.Context) (map[string]float64, time.Time, error)
+}
+
+// RatesDocument is the JSON rates document read by FileRateProvider and HTTPRateProvider,
+// e.g. {"base": "USD", "as_of": "2025-03-14T16:00:00Z", "rates": {"EUR": 0.92}}
+// fields other than these are ignored
+type RatesDocument struct {
+	// Base is the currency of the rates, when set it must be the base currency of the provider
+	Base string `json:"base,omitempty"`
+	// AsOf is the time the rates were published
+	AsOf time.Time `json:"as_of"`
+	// Rates is the amount of every currency one unit of the base currency buys
+	Rates map[string]float64 `json:"rates"`
+}
+
+// parseRatesDocument decodes and validates a RatesDocument with the rates of baseCurrency
+func parseRatesDocument(data []byte, baseCurrency string) (map[string]float64, time.Time, error) {
+	var doc RatesDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: failed to decode rates: %w", ErrInvalidRates, err)
+	}
+	if doc.Base != "" && doc.Base != baseCurrency {
+		return nil, time.Time{}, fmt.Errorf("%w: rates are for the base currency %s, not %s", ErrInvalidRates, doc.Base, baseCurrency)
+	}
+	if err := validateRates(doc.Rates); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: %w", ErrInvalidRates, err)
+	}
+
+	return doc.Rates, doc.AsOf, nil
+}
+
+// FileRateProvider reads the rates from a file, a RatesDocument or, with the .xml extension, ECB reference rates
+type FileRateProvider struct {
+	path         string
+	baseCurrency string
+}
+
+var _ RateProvider = (*FileRateProvider)(nil)
+
+// NewFileRateProvider returns a provider of the rates of baseCurrency in the file at path
+// ECB reference rates are for EUR, their newest day is used
+func NewFileRateProvider(path, baseCurrency string) *FileRateProvider {
+	return &FileRateProvider{
+		path:         path,
+		baseCurrency: baseCurrency,
+	}
+}
+
+// Fetch reads the file, the rates are as of their AsOf or ECB date, or else the modification time of the file
+func (p *FileRateProvider) Fetch(ctx context.Context) (map[string]float64, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if strings.ToLower(filepath.Ext(p.path)) == ".xml" {
+		if p.baseCurrency != CurrencyEUR {
+			return nil, time.Time{}, fmt.Errorf("ECB rates %s are for the base currency %s, not %s", p.path, CurrencyEUR, p.baseCurrency)
+		}
+		ecb, err := LoadECBRates(p.path)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		latest := ecb.Latest()
+		return latest.Rates, latest.Date, nil
+	}
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read rates %s: %w", p.path, err)
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read rates %s: %w", p.path, err)
+	}
+
+	rates, asOf, err := parseRatesDocument(data, p.baseCurrency)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", p.path, err)
+	}
+	if asOf.IsZero() {
+		asOf = info.ModTime()
+	}
+
+	return rates, asOf, nil
+}
+
+// HTTPRateProvider gets the rates as a JSON RatesDocument from a URL
+type HTTPRateProvider struct {
+	url          string
+	baseCurrency string
+	client       *http.Client
+}
+
+var _ RateProvider = (*HTTPRateProvider)(nil)
+
+// NewHTTPRateProvider returns a provider of the rates of baseCurrency at url, http.DefaultClient is used when client is nil
+func NewHTTPRateProvider(url, baseCurrency string, client *http.Client) *HTTPRateProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPRateProvider{
+		url:          url,
+		baseCurrency: baseCurrency,
+		client:       client,
+	}
+}
+
+// Fetch gets the rates, any response other than 200 OK is an error
+func (p *HTTPRateProvider) Fetch(ctx context.Context) (map[string]float64, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to create rates request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("failed to get rates %s: %s", p.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRatesSize))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read rates %s: %w", p.url, err)
+	}
+
+	rates, asOf, err := parseRatesDocument(data, p.baseCurrency)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", p.url, err)
+	}
+
+	return rates, asOf, nil
+}
@@ -0,0 +1,202 @@
+package aicost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileRateProvider_Fetch(t *testing.T) {
+	asOf := time.Date(2025, 3, 14, 16, 0, 0, 0, time.UTC)
+	modTime := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		base      string
+		wantRates map[string]float64
+		wantAsOf  time.Time
+		wantErr   bool
+		errIs     error
+	}{
+		{
+			name:      "json document",
+			file:      "rates.json",
+			content:   `{"base": "USD", "as_of": "2025-03-14T16:00:00Z", "rates": {"EUR": 0.92, "JPY": 148.5}}`,
+			base:      "USD",
+			wantRates: map[string]float64{"EUR": 0.92, "JPY": 148.5},
+			wantAsOf:  asOf,
+		},
+		{
+			name:      "modification time without as_of",
+			file:      "rates.json",
+			content:   `{"rates": {"EUR": 0.92}, "disclaimer": "ignored"}`,
+			base:      "USD",
+			wantRates: map[string]float64{"EUR": 0.92},
+			wantAsOf:  modTime,
+		},
+		{
+			name:      "ecb xml",
+			file:      "eurofxref-daily.xml",
+			content:   ecbDaily,
+			base:      "EUR",
+			wantRates: map[string]float64{"USD": 1.0885, "JPY": 161.79, "GBP": 0.84015},
+			wantAsOf:  time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "ecb xml for another base",
+			file:    "eurofxref-daily.xml",
+			content: ecbDaily,
+			base:    "USD",
+			wantErr: true,
+		},
+		{
+			name:    "other base",
+			file:    "rates.json",
+			content: `{"base": "EUR", "rates": {"USD": 1.08}}`,
+			base:    "USD",
+			wantErr: true,
+			errIs:   ErrInvalidRates,
+		},
+		{
+			name:    "no rates",
+			file:    "rates.json",
+			content: `{"base": "USD", "rates": {}}`,
+			base:    "USD",
+			wantErr: true,
+			errIs:   ErrInvalidRates,
+		},
+		{
+			name:    "unknown currency",
+			file:    "rates.json",
+			content: `{"rates": {"XYZ": 2}}`,
+			base:    "USD",
+			wantErr: true,
+			errIs:   ErrUnknownCurrency,
+		},
+		{
+			name:    "invalid json",
+			file:    "rates.json",
+			content: `{"rates": `,
+			base:    "USD",
+			wantErr: true,
+			errIs:   ErrInvalidRates,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			assert.NoError(t, os.Chtimes(path, modTime, modTime))
+
+			rates, got, err := NewFileRateProvider(path, tt.base).Fetch(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Nil(t, rates)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRates, rates)
+			assert.True(t, tt.wantAsOf.Equal(got), "as of %s", got)
+		})
+	}
+}
+
+func Test_FileRateProvider_Fetch_Missing(t *testing.T) {
+	_, _, err := NewFileRateProvider(filepath.Join(t.TempDir(), "rates.json"), "USD").Fetch(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_HTTPRateProvider_Fetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantRates map[string]float64
+		wantAsOf  time.Time
+		wantErr   bool
+		errIs     error
+	}{
+		{
+			name:      "rates",
+			status:    http.StatusOK,
+			body:      `{"base": "USD", "as_of": "2025-03-14T16:00:00Z", "rates": {"EUR": 0.92}}`,
+			wantRates: map[string]float64{"EUR": 0.92},
+			wantAsOf:  time.Date(2025, 3, 14, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "no as_of",
+			status:    http.StatusOK,
+			body:      `{"rates": {"EUR": 0.92}}`,
+			wantRates: map[string]float64{"EUR": 0.92},
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			body:    `{"error": "unavailable"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			status:  http.StatusOK,
+			body:    `<html></html>`,
+			wantErr: true,
+			errIs:   ErrInvalidRates,
+		},
+		{
+			name:    "zero rate",
+			status:  http.StatusOK,
+			body:    `{"rates": {"EUR": 0}}`,
+			wantErr: true,
+			errIs:   ErrInvalidRates,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "application/json", r.Header.Get("Accept"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			rates, asOf, err := NewHTTPRateProvider(server.URL, "USD", server.Client()).Fetch(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				assert.Nil(t, rates)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRates, rates)
+			assert.True(t, tt.wantAsOf.Equal(asOf), "as of %s", asOf)
+		})
+	}
+}
+
+func Test_HTTPRateProvider_Fetch_Canceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"rates": {"EUR": 0.92}}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := NewHTTPRateProvider(server.URL, "USD", nil).Fetch(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
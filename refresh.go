@@ -0,0 +1,214 @@
+package aicost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrNoRates = errors.New("no conversion rates fetched yet")
+var ErrStaleRates = errors.New("conversion rates are stale")
+
+// RefreshingConverter converts with the rates of a RateProvider, fetched again on every interval, safe for concurrent use
+// a failed fetch is reported and the last good rates are kept, rates older than the TTL are stale,
+// see Stale and RejectStale
+type RefreshingConverter struct {
+	baseCurrency string
+	provider     RateProvider
+	interval     time.Duration
+	ttl          time.Duration
+	now          func() time.Time
+
+	mu          sync.RWMutex
+	converter   *converter
+	asOf        time.Time
+	rejectStale bool
+	// staleReported is the time of the rates last reported stale to onError
+	staleReported time.Time
+	onRefresh     func(rates map[string]float64, asOf time.Time)
+	onError       func(error)
+}
+
+var _ Converter = (*RefreshingConverter)(nil)
+
+// NewRefreshingConverter returns a converter of the rates of baseCurrency fetched from provider
+// the rates are stale once older than ttl, measured from their publication time or else from the fetch, 0 never expires them
+func NewRefreshingConverter(baseCurrency string, provider RateProvider, interval, ttl time.Duration) *RefreshingConverter {
+	return &RefreshingConverter{
+		baseCurrency: baseCurrency,
+		provider:     provider,
+		interval:     interval,
+		ttl:          ttl,
+		now:          time.Now,
+	}
+}
+
+// OnRefresh sets the function called with every fetched set of rates
+func (r *RefreshingConverter) OnRefresh(fn func(rates map[string]float64, asOf time.Time)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onRefresh = fn
+}
+
+// OnError sets the function called with every failed fetch and once when the rates of a fetch become stale
+func (r *RefreshingConverter) OnError(fn func(error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onError = fn
+}
+
+// RejectStale sets whether Convert fails with ErrStaleRates once the rates are older than the TTL,
+// by default the stale rates are used until the next successful refresh
+func (r *RefreshingConverter) RejectStale(reject bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rejectStale = reject
+}
+
+// Refresh fetches the rates and swaps them in, on error the previous rates are kept
+func (r *RefreshingConverter) Refresh(ctx context.Context) error {
+	rates, asOf, err := r.provider.Fetch(ctx)
+	if err == nil {
+		err = r.set(rates, asOf)
+	}
+
+	r.mu.RLock()
+	onRefresh, onError := r.onRefresh, r.onError
+	r.mu.RUnlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to refresh conversion rates: %w", err)
+		if onError != nil {
+			onError(err)
+		}
+		return err
+	}
+
+	if onRefresh != nil {
+		onRefresh(rates, asOf)
+	}
+
+	return nil
+}
+
+// Rates sets the rates directly, as of now, until the next refresh
+func (r *RefreshingConverter) Rates(rates map[string]float64) error {
+	return r.set(rates, time.Time{})
+}
+
+// set swaps in a converter of rates, a zero asOf is the current time
+func (r *RefreshingConverter) set(rates map[string]float64, asOf time.Time) error {
+	c := NewConverter(r.baseCurrency, nil)
+	if err := c.Rates(rates); err != nil {
+		return err
+	}
+	if asOf.IsZero() {
+		asOf = r.now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.converter = c
+	r.asOf = asOf
+
+	return nil
+}
+
+// AsOf returns the time of the current rates, the zero time before the first refresh
+func (r *RefreshingConverter) AsOf() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.asOf
+}
+
+// Stale reports whether there are no rates or the rates are older than the TTL
+func (r *RefreshingConverter) Stale() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, err := r.current()
+	return err != nil
+}
+
+// current returns the converter of the current rates, ErrNoRates when there are none
+// and the converter with ErrStaleRates when they are older than the TTL, the caller holds the lock
+func (r *RefreshingConverter) current() (*converter, error) {
+	if r.converter == nil {
+		return nil, ErrNoRates
+	}
+	if r.ttl > 0 && r.now().Sub(r.asOf) > r.ttl {
+		return r.converter, fmt.Errorf("%w: as of %s, older than %s", ErrStaleRates, r.asOf.Format(time.RFC3339), r.ttl)
+	}
+
+	return r.converter, nil
+}
+
+// Convert converts an amount with the current rates, see converter.Convert
+// it fails with ErrNoRates before the first refresh, stale rates are reported to OnError and used unless RejectStale is set
+func (r *RefreshingConverter) Convert(amount Money, toCurrency string) (*Money, error) {
+	// the same currency needs no rates
+	if amount.CurrencyCode == toCurrency {
+		if err := validateCurrency(toCurrency); err != nil {
+			return nil, fmt.Errorf("target currency: %w", err)
+		}
+		return &amount, nil
+	}
+
+	r.mu.RLock()
+	c, err := r.current()
+	reject := r.rejectStale
+	r.mu.RUnlock()
+	if c == nil || (err != nil && reject) {
+		return nil, err
+	}
+	if err != nil {
+		r.reportStale(err)
+	}
+
+	return c.Convert(amount, toCurrency)
+}
+
+// reportStale reports the stale rates to OnError, once for every AsOf
+func (r *RefreshingConverter) reportStale(err error) {
+	r.mu.Lock()
+	if r.staleReported.Equal(r.asOf) {
+		r.mu.Unlock()
+		return
+	}
+	r.staleReported = r.asOf
+	onError := r.onError
+	r.mu.Unlock()
+
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// Run refreshes the rates right away and then on every interval until ctx is done
+// refresh errors are reported to OnError and do not stop the refreshes, an interval that is not positive is an error
+func (r *RefreshingConverter) Run(ctx context.Context) error {
+	if r.interval <= 0 {
+		return fmt.Errorf("rates refresh interval must be greater than 0: %s", r.interval)
+	}
+
+	_ = r.Refresh(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_ = r.Refresh(ctx)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package aicost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRateProvider returns its rates, or err when set
+type stubRateProvider struct {
+	mu    sync.Mutex
+	rates map[string]float64
+	asOf  time.Time
+	err   error
+}
+
+func (p *stubRateProvider) Fetch(ctx context.Context) (map[string]float64, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return nil, time.Time{}, p.err
+	}
+
+	return p.rates, p.asOf, nil
+}
+
+func (p *stubRateProvider) set(rates map[string]float64, asOf time.Time, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rates, p.asOf, p.err = rates, asOf, err
+}
+
+func Test_RefreshingConverter_Refresh(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	provider := &stubRateProvider{}
+	converter := NewRefreshingConverter("USD", provider, time.Minute, time.Hour)
+	converter.now = func() time.Time { return now }
+
+	var reported []error
+	var refreshed []time.Time
+	converter.OnError(func(err error) { reported = append(reported, err) })
+	converter.OnRefresh(func(_ map[string]float64, asOf time.Time) { refreshed = append(refreshed, asOf) })
+
+	usd := Money{Units: 100, CurrencyCode: "USD"}
+
+	// no rates before the first refresh, the same currency needs none
+	_, err := converter.Convert(usd, "EUR")
+	assert.ErrorIs(t, err, ErrNoRates)
+	assert.True(t, converter.Stale())
+	same, err := converter.Convert(usd, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, &usd, same)
+
+	// the first refresh fails
+	provider.set(nil, time.Time{}, errors.New("unavailable"))
+	assert.Error(t, converter.Refresh(context.Background()))
+	_, err = converter.Convert(usd, "EUR")
+	assert.ErrorIs(t, err, ErrNoRates)
+
+	// rates with a publication time
+	provider.set(map[string]float64{"EUR": 0.92}, now.Add(-10*time.Minute), nil)
+	assert.NoError(t, converter.Refresh(context.Background()))
+	assert.Equal(t, now.Add(-10*time.Minute), converter.AsOf())
+	assert.False(t, converter.Stale())
+	got, err := converter.Convert(usd, "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 92, CurrencyCode: "EUR"}, got)
+
+	// a failed refresh keeps the last good rates
+	provider.set(nil, time.Time{}, errors.New("unavailable"))
+	assert.Error(t, converter.Refresh(context.Background()))
+	got, err = converter.Convert(usd, "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 92, CurrencyCode: "EUR"}, got)
+
+	// invalid rates keep the last good rates
+	provider.set(map[string]float64{"EUR": -1}, now, nil)
+	assert.Error(t, converter.Refresh(context.Background()))
+	assert.Equal(t, now.Add(-10*time.Minute), converter.AsOf())
+
+	// and uses them once they are older than the TTL, reported once
+	now = now.Add(time.Hour)
+	assert.True(t, converter.Stale())
+	for i := 0; i < 2; i++ {
+		got, err = converter.Convert(usd, "EUR")
+		assert.NoError(t, err)
+		assert.Equal(t, &Money{Units: 92, CurrencyCode: "EUR"}, got)
+	}
+	if assert.Len(t, reported, 4) {
+		assert.ErrorIs(t, reported[3], ErrStaleRates)
+	}
+
+	// unless stale rates are rejected
+	converter.RejectStale(true)
+	_, err = converter.Convert(usd, "EUR")
+	assert.ErrorIs(t, err, ErrStaleRates)
+
+	// rates without a publication time are as of the fetch
+	provider.set(map[string]float64{"EUR": 0.93}, time.Time{}, nil)
+	assert.NoError(t, converter.Refresh(context.Background()))
+	assert.Equal(t, now, converter.AsOf())
+	got, err = converter.Convert(usd, "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 93, CurrencyCode: "EUR"}, got)
+
+	assert.Len(t, reported, 4)
+	assert.Len(t, refreshed, 2)
+}
+
+func Test_RefreshingConverter_Rates(t *testing.T) {
+	converter := NewRefreshingConverter("USD", &stubRateProvider{}, time.Minute, 0)
+
+	assert.Error(t, converter.Rates(map[string]float64{}))
+	assert.True(t, converter.Stale())
+
+	assert.NoError(t, converter.Rates(testRates))
+	assert.False(t, converter.Stale())
+
+	// without a TTL the rates never expire
+	converter.now = func() time.Time { return time.Now().AddDate(1, 0, 0) }
+	got, err := converter.Convert(Money{Units: 100, CurrencyCode: "USD"}, "GBP")
+	assert.NoError(t, err)
+	assert.Equal(t, &Money{Units: 75, CurrencyCode: "GBP"}, got)
+}
+
+func Test_RefreshingConverter_Run_Interval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		provider := &stubRateProvider{rates: map[string]float64{"EUR": 0.92}}
+		converter := NewRefreshingConverter("USD", provider, interval, time.Minute)
+
+		assert.Error(t, converter.Run(context.Background()))
+		assert.True(t, converter.AsOf().IsZero())
+	}
+}
+
+func Test_RefreshingConverter_Run(t *testing.T) {
+	var mu sync.Mutex
+	rate := "0.92"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write([]byte(`{"base": "USD", "rates": {"EUR": ` + rate + `}}`))
+	}))
+	defer server.Close()
+
+	converter := NewRefreshingConverter("USD", NewHTTPRateProvider(server.URL, "USD", server.Client()), 5*time.Millisecond, time.Minute)
+	accountant := NewAccountant(nil, converter, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- converter.Run(ctx)
+	}()
+
+	converted := func() int64 {
+		_, got, err := accountant.CostForModelInput("openai", "gpt-4o", "EUR", 1_000_000)
+		if err != nil {
+			return 0
+		}
+		return got.Units
+	}
+
+	// gpt-4o input is USD 2.50 per 1M tokens
+	assert.Eventually(t, func() bool { return converted() == 2 }, time.Second, time.Millisecond)
+
+	mu.Lock()
+	rate = "1.2"
+	mu.Unlock()
+	assert.Eventually(t, func() bool { return converted() == 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
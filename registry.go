@@ -0,0 +1,99 @@
+package aicost
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// registry holds the current model index, readers never lock and writers swap the whole index
+type registry struct {
+	current atomic.Pointer[modelIndex]
+}
+
+// modelIndex is an immutable snapshot of the models keyed by provider and name
+type modelIndex struct {
+	models []Model
+	// byName maps provider/model to the positions of its entries, one per effective window
+	byName map[string][]int
+	// aliases maps provider/alias to the model name
+	aliases map[string]string
+}
+
+func newRegistry(models []Model) *registry {
+	r := &registry{}
+	r.store(models)
+
+	return r
+}
+
+// load returns the current snapshot, it must not be modified
+func (r *registry) load() *modelIndex {
+	return r.current.Load()
+}
+
+// store indexes a deep copy of models and makes it the current snapshot
+func (r *registry) store(models []Model) {
+	r.current.Store(newModelIndex(models))
+}
+
+func newModelIndex(models []Model) *modelIndex {
+	idx := &modelIndex{
+		models:  cloneModels(models),
+		byName:  make(map[string][]int, len(models)),
+		aliases: make(map[string]string),
+	}
+
+	for i, m := range idx.models {
+		key := registryKey(m.Provider, m.Model)
+		idx.byName[key] = append(idx.byName[key], i)
+		for _, alias := range m.Aliases {
+			aliasKey := registryKey(m.Provider, alias)
+			// the first model listing an alias wins, like the linear scan did
+			if _, ok := idx.aliases[aliasKey]; !ok {
+				idx.aliases[aliasKey] = m.Model
+			}
+		}
+	}
+
+	return idx
+}
+
+func registryKey(provider, model string) string {
+	return provider + "/" + model
+}
+
+// all returns a deep copy of the models of the snapshot
+func (idx *modelIndex) all() []Model {
+	return cloneModels(idx.models)
+}
+
+// known reports whether the provider prices a model under exactly this name
+func (idx *modelIndex) known(provider, model string) bool {
+	_, ok := idx.byName[registryKey(provider, model)]
+	return ok
+}
+
+// aliasTarget returns the name of the model listing alias in its aliases, empty when there is none
+func (idx *modelIndex) aliasTarget(provider, alias string) string {
+	return idx.aliases[registryKey(provider, alias)]
+}
+
+// findExactAt returns a deep copy of the model named exactly model with the prices effective at the given time
+// it returns ErrPricingModelNotFound for an unknown model
+// and ErrPricingNotEffective when the model is known but none of its prices apply at that time
+func (idx *modelIndex) findExactAt(provider, model string, at time.Time) (*Model, error) {
+	positions, ok := idx.byName[registryKey(provider, model)]
+	if !ok {
+		return nil, ErrPricingModelNotFound
+	}
+
+	for _, i := range positions {
+		if idx.models[i].EffectiveAt(at) {
+			m := idx.models[i].clone()
+			return &m, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s at %s: %w", model, at.Format(time.RFC3339), ErrPricingNotEffective)
+}
@@ -0,0 +1,195 @@
+package aicost
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_registry_store(t *testing.T) {
+	models := []Model{
+		{
+			Provider:        "openai",
+			Model:           "gpt-4",
+			CostInput:       Money{Units: 0, Nanos: 30000, CurrencyCode: "USD"},
+			CostOutput:      Money{Units: 0, Nanos: 60000, CurrencyCode: "USD"},
+			CostCachedInput: &Money{Units: 0, Nanos: 5, CurrencyCode: "USD"},
+			Tiers: []PriceTier{
+				{
+					Name:              "long-context",
+					AbovePromptTokens: 1000,
+					CostInput:         Money{Units: 0, Nanos: 60000, CurrencyCode: "USD"},
+					CostOutput:        Money{Units: 0, Nanos: 120000, CurrencyCode: "USD"},
+					CostCachedInput:   &Money{Units: 0, Nanos: 10, CurrencyCode: "USD"},
+				},
+			},
+		},
+		{
+			Provider:   "anthropic",
+			Model:      "claude-3",
+			Aliases:    []string{"claude-3-latest"},
+			CostInput:  Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+		},
+	}
+
+	r := newRegistry(models)
+	idx := r.load()
+
+	assert.True(t, idx.known("openai", "gpt-4"))
+	assert.False(t, idx.known("anthropic", "gpt-4"))
+	assert.Equal(t, "claude-3", idx.aliasTarget("anthropic", "claude-3-latest"))
+	assert.Equal(t, "", idx.aliasTarget("openai", "claude-3-latest"))
+
+	// the snapshot does not share memory with the caller
+	models[0].CostInput.Nanos = 1
+	got, err := idx.findExactAt("openai", "gpt-4", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30000), got.CostInput.Nanos)
+
+	// nor through the optional prices and the tiers
+	models[0].CostCachedInput.Nanos = 999
+	models[0].Tiers[0].CostInput.Nanos = 999
+	models[0].Tiers[0].CostCachedInput.Nanos = 999
+	got, err = idx.findExactAt("openai", "gpt-4", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), got.CostCachedInput.Nanos)
+	assert.Equal(t, int32(60000), got.Tiers[0].CostInput.Nanos)
+	assert.Equal(t, int32(10), got.Tiers[0].CostCachedInput.Nanos)
+
+	// a returned model does not reach the snapshot
+	got.CostInput.Nanos = 2
+	got.CostCachedInput.Nanos = 2
+	got.Tiers[0].CostCachedInput.Nanos = 2
+	again, err := idx.findExactAt("openai", "gpt-4", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30000), again.CostInput.Nanos)
+	assert.Equal(t, int32(5), again.CostCachedInput.Nanos)
+	assert.Equal(t, int32(10), again.Tiers[0].CostCachedInput.Nanos)
+
+	all := idx.all()
+	all[0].CostCachedInput.Nanos = 3
+	all[0].Tiers[0].AbovePromptTokens = 3
+	assert.Equal(t, int32(5), idx.all()[0].CostCachedInput.Nanos)
+	assert.Equal(t, int64(1000), idx.all()[0].Tiers[0].AbovePromptTokens)
+
+	// a swap does not affect a loaded snapshot
+	r.store(models[:1])
+	assert.True(t, idx.known("anthropic", "claude-3"))
+	assert.False(t, r.load().known("anthropic", "claude-3"))
+}
+
+func Test_Counter_Models_ConcurrentSwap(t *testing.T) {
+	cheap := []Model{testRegistryModel("openai", "gpt-4", 1000)}
+	expensive := []Model{testRegistryModel("openai", "gpt-4", 2000)}
+
+	accountant := NewAccountant(cheap, NewConverter("USD", testRates), false)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				accountant.Models(expensive)
+			} else {
+				accountant.Models(cheap)
+			}
+		}
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				cost, _, err := accountant.CostForModelInput("openai", "gpt-4", "USD", 1000)
+				assert.NoError(t, err)
+				assert.Contains(t, []int32{1000000, 2000000}, cost.Nanos)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func testRegistryModel(provider, model string, nanos int32) Model {
+	return Model{
+		Provider:   provider,
+		Model:      model,
+		CostInput:  Money{Units: 0, Nanos: nanos, CurrencyCode: "USD"},
+		CostOutput: Money{Units: 0, Nanos: nanos * 2, CurrencyCode: "USD"},
+	}
+}
+
+func benchmarkModels(n int) []Model {
+	models := make([]Model, 0, n)
+	for i := 0; i < n; i++ {
+		models = append(models, testRegistryModel(fmt.Sprintf("provider-%d", i%10), fmt.Sprintf("model-%d", i), int32(i%1000+1)))
+	}
+
+	return models
+}
+
+func Benchmark_Counter_CostForModelInput(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		b.Run(fmt.Sprintf("models=%d", n), func(b *testing.B) {
+			models := benchmarkModels(n)
+			accountant := NewAccountant(models, NewConverter("USD", testRates), false)
+			last := models[len(models)-1]
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := accountant.CostForModelInput(last.Provider, last.Model, "USD", 1000); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_Converter_Convert(b *testing.B) {
+	// 30 rates
+	rates := map[string]float64{"EUR": 0.85, "GBP": 0.75}
+	for _, cur := range iso4217 {
+		if len(rates) == 30 {
+			break
+		}
+		if _, ok := rates[cur.Code]; !ok && cur.Code != "USD" {
+			rates[cur.Code] = 1.25
+		}
+	}
+	c := NewConverter("USD", nil)
+	if err := c.Rates(rates); err != nil {
+		b.Fatal(err)
+	}
+	amount := Money{Units: 100, CurrencyCode: "EUR"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Convert(amount, "GBP"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_Counter_CostForModelInput_Parallel(b *testing.B) {
+	models := benchmarkModels(5000)
+	accountant := NewAccountant(models, NewConverter("USD", testRates), false)
+	last := models[len(models)-1]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, _, err := accountant.CostForModelInput(last.Provider, last.Model, "USD", 1000); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
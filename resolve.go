@@ -0,0 +1,94 @@
+package aicost
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// snapshotPattern matches dated snapshot names, e.g. gpt-4o-2024-08-06 or claude-3-5-sonnet-20241022
+var snapshotPattern = regexp.MustCompile(`^(.+)-(\d{4}-\d{2}-\d{2}|\d{8})$`)
+
+// Resolution records how a requested model name was resolved to a priced model
+type Resolution struct {
+	// Requested is the model name the caller asked for
+	Requested string `json:"requested" yaml:"requested"`
+	// Model is the name of the priced model
+	Model string `json:"model" yaml:"model"`
+	// Steps describes every rewrite of the name, in order
+	Steps []string `json:"steps" yaml:"steps"`
+}
+
+// ModelNotFoundError is returned when no resolution of a model name is priced
+type ModelNotFoundError struct {
+	Provider  string
+	Requested string
+	// Tried holds every name that was looked up
+	Tried []string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	return fmt.Sprintf("%s/%s, tried %s: %v", e.Provider, e.Requested, strings.Join(e.Tried, ", "), ErrPricingModelNotFound)
+}
+
+func (e *ModelNotFoundError) Unwrap() error {
+	return ErrPricingModelNotFound
+}
+
+// SnapshotBase returns the base model of a dated snapshot name
+func SnapshotBase(model string) (string, bool) {
+	match := snapshotPattern.FindStringSubmatch(model)
+	if match == nil {
+		return "", false
+	}
+
+	return match[1], true
+}
+
+// ResolveModel returns the model priced for the requested name at the given time, with the resolution chain
+// the name is resolved in order: exact model name, alias, then the same for the base of a dated snapshot
+// "-latest" names are not guessed, they resolve only when a model lists them in its aliases
+func (p *Counter) ResolveModel(provider, model string, at time.Time) (*Model, *Resolution, error) {
+	// every step reads the same snapshot, a concurrent Models swap cannot mix two catalogs
+	idx := p.models.load()
+	resolution := &Resolution{Requested: model}
+	var tried []string
+
+	names := []string{model}
+	if base, ok := SnapshotBase(model); ok {
+		names = append(names, base)
+	}
+
+	for i, name := range names {
+		var steps []string
+		if i > 0 {
+			steps = append(steps, fmt.Sprintf("snapshot %s -> %s", model, name))
+		}
+
+		target := name
+		if !idx.known(provider, name) {
+			tried = append(tried, name)
+			alias := idx.aliasTarget(provider, name)
+			if alias == "" {
+				continue
+			}
+			steps = append(steps, fmt.Sprintf("alias %s -> %s", name, alias))
+			target = alias
+		}
+
+		resolution.Model = target
+		resolution.Steps = steps
+		found, err := idx.findExactAt(provider, target, at)
+		if err != nil {
+			if len(steps) > 0 {
+				return nil, resolution, fmt.Errorf("%s (%s): %w", model, strings.Join(steps, ", "), err)
+			}
+			return nil, resolution, err
+		}
+
+		return found, resolution, nil
+	}
+
+	return nil, nil, &ModelNotFoundError{Provider: provider, Requested: model, Tried: tried}
+}
@@ -0,0 +1,176 @@
+package aicost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SnapshotBase(t *testing.T) {
+	tests := []struct {
+		model    string
+		wantBase string
+		wantOk   bool
+	}{
+		{model: "gpt-4o-2024-08-06", wantBase: "gpt-4o", wantOk: true},
+		{model: "claude-3-5-sonnet-20241022", wantBase: "claude-3-5-sonnet", wantOk: true},
+		{model: "o3-mini-2025-01-31", wantBase: "o3-mini", wantOk: true},
+		{model: "gpt-4o", wantBase: "", wantOk: false},
+		{model: "claude-3-5-sonnet-latest", wantBase: "", wantOk: false},
+		{model: "gpt-4-0613", wantBase: "", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			base, ok := SnapshotBase(tt.model)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantBase, base)
+		})
+	}
+}
+
+func Test_Counter_ResolveModel(t *testing.T) {
+	priceChange := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Setup test models
+	testModels := []Model{
+		{
+			Provider:   "openai",
+			Model:      "gpt-4o",
+			CostInput:  Money{Units: 0, Nanos: 2500, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 10000, CurrencyCode: "USD"},
+		},
+		{
+			Provider:   "openai",
+			Model:      "gpt-4o-2024-05-13",
+			CostInput:  Money{Units: 0, Nanos: 5000, CurrencyCode: "USD"},
+			CostOutput: Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+		},
+		{
+			Provider:      "anthropic",
+			Model:         "claude-3-5-sonnet",
+			Aliases:       []string{"claude-3-5-sonnet-latest"},
+			CostInput:     Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"},
+			CostOutput:    Money{Units: 0, Nanos: 15000, CurrencyCode: "USD"},
+			EffectiveFrom: &priceChange,
+		},
+	}
+
+	con := NewConverter("USD", testRates)
+	accountant := NewAccountant(testModels, con, false)
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		provider       string
+		model          string
+		at             time.Time
+		wantModel      *Model
+		wantResolution *Resolution
+		wantErr        error
+		wantErrMsg     string
+	}{
+		{
+			name:           "exact name",
+			provider:       "openai",
+			model:          "gpt-4o",
+			at:             now,
+			wantModel:      &testModels[0],
+			wantResolution: &Resolution{Requested: "gpt-4o", Model: "gpt-4o"},
+		},
+		{
+			name:      "dated snapshot",
+			provider:  "openai",
+			model:     "gpt-4o-2024-08-06",
+			at:        now,
+			wantModel: &testModels[0],
+			wantResolution: &Resolution{
+				Requested: "gpt-4o-2024-08-06",
+				Model:     "gpt-4o",
+				Steps:     []string{"snapshot gpt-4o-2024-08-06 -> gpt-4o"},
+			},
+		},
+		{
+			name:           "priced snapshot wins over its base",
+			provider:       "openai",
+			model:          "gpt-4o-2024-05-13",
+			at:             now,
+			wantModel:      &testModels[1],
+			wantResolution: &Resolution{Requested: "gpt-4o-2024-05-13", Model: "gpt-4o-2024-05-13"},
+		},
+		{
+			name:      "latest alias",
+			provider:  "anthropic",
+			model:     "claude-3-5-sonnet-latest",
+			at:        now,
+			wantModel: &testModels[2],
+			wantResolution: &Resolution{
+				Requested: "claude-3-5-sonnet-latest",
+				Model:     "claude-3-5-sonnet",
+				Steps:     []string{"alias claude-3-5-sonnet-latest -> claude-3-5-sonnet"},
+			},
+		},
+		{
+			name:      "dated snapshot of a family",
+			provider:  "anthropic",
+			model:     "claude-3-5-sonnet-20241022",
+			at:        now,
+			wantModel: &testModels[2],
+			wantResolution: &Resolution{
+				Requested: "claude-3-5-sonnet-20241022",
+				Model:     "claude-3-5-sonnet",
+				Steps:     []string{"snapshot claude-3-5-sonnet-20241022 -> claude-3-5-sonnet"},
+			},
+		},
+		{
+			name:       "resolved but not effective",
+			provider:   "anthropic",
+			model:      "claude-3-5-sonnet-latest",
+			at:         priceChange.Add(-time.Hour),
+			wantErr:    ErrPricingNotEffective,
+			wantErrMsg: "claude-3-5-sonnet-latest (alias claude-3-5-sonnet-latest -> claude-3-5-sonnet)",
+		},
+		{
+			name:       "latest is not guessed",
+			provider:   "openai",
+			model:      "gpt-4o-latest",
+			at:         now,
+			wantErr:    ErrPricingModelNotFound,
+			wantErrMsg: "openai/gpt-4o-latest, tried gpt-4o-latest",
+		},
+		{
+			name:       "unknown snapshot",
+			provider:   "openai",
+			model:      "gpt-5-2025-08-07",
+			at:         now,
+			wantErr:    ErrPricingModelNotFound,
+			wantErrMsg: "openai/gpt-5-2025-08-07, tried gpt-5-2025-08-07, gpt-5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, resolution, err := accountant.ResolveModel(tt.provider, tt.model, tt.at)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorContains(t, err, tt.wantErrMsg)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantModel, got)
+			assert.Equal(t, tt.wantResolution, resolution)
+		})
+	}
+
+	breakdown, err := accountant.CostForUsage("anthropic", "claude-3-5-sonnet-latest", "USD", Usage{InputTokens: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, "claude-3-5-sonnet", breakdown.Model)
+	assert.Equal(t, []string{"alias claude-3-5-sonnet-latest -> claude-3-5-sonnet"}, breakdown.Resolution.Steps)
+
+	_, _, err = accountant.CostForModelInput("openai", "gpt-5", "USD", 1000)
+	var notFound *ModelNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.Equal(t, []string{"gpt-5"}, notFound.Tried)
+}
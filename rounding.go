@@ -0,0 +1,118 @@
+package aicost
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RoundingMode is the way an amount is rounded to a number of decimal places
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest value, ties to the even neighbour (banker's rounding)
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest value, ties away from zero, like math.Round
+	RoundHalfUp
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundDown rounds toward zero, truncating
+	RoundDown
+	// RoundCeiling rounds toward positive infinity
+	RoundCeiling
+	// RoundFloor rounds toward negative infinity
+	RoundFloor
+)
+
+func (r RoundingMode) String() string {
+	switch r {
+	case RoundHalfEven:
+		return "half-even"
+	case RoundHalfUp:
+		return "half-up"
+	case RoundUp:
+		return "up"
+	case RoundDown:
+		return "down"
+	case RoundCeiling:
+		return "ceiling"
+	case RoundFloor:
+		return "floor"
+	}
+
+	return fmt.Sprintf("RoundingMode(%d)", int(r))
+}
+
+// Round rounds m to places decimal places, from 0 to 9, with the given mode
+// m is normalized, an empty or unknown currency is rejected
+func (m *Money) Round(places int, mode RoundingMode) (*Money, error) {
+	if places < 0 || places > 9 {
+		return nil, fmt.Errorf("decimal places must be between 0 and 9: %d", places)
+	}
+	total, err := m.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	// the step between two values with that many places, in nanos
+	step := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(9-places)), nil)
+
+	steps, err := roundRat(new(big.Rat).SetFrac(total, step), mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return moneyFromNanos(m.CurrencyCode, steps.Mul(steps, step))
+}
+
+// RoundToMinorUnits rounds m to the minor units of its currency, e.g. cents for USD or yen for JPY
+func (m *Money) RoundToMinorUnits(mode RoundingMode) (*Money, error) {
+	return m.Round(minorUnits(m.CurrencyCode), mode)
+}
+
+// roundRat rounds r to an integer with the given mode
+func roundRat(r *big.Rat, mode RoundingMode) (*big.Int, error) {
+	// quo is truncated toward zero and rem has the sign of r
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return quo, nil
+	}
+
+	// away moves quo one step away from zero
+	away := func() *big.Int {
+		return quo.Add(quo, big.NewInt(int64(r.Sign())))
+	}
+
+	// half compares the dropped fraction with one half
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(r.Denom())
+
+	switch mode {
+	case RoundHalfEven:
+		if half > 0 || (half == 0 && quo.Bit(0) == 1) {
+			return away(), nil
+		}
+		return quo, nil
+	case RoundHalfUp:
+		if half >= 0 {
+			return away(), nil
+		}
+		return quo, nil
+	case RoundUp:
+		return away(), nil
+	case RoundDown:
+		return quo, nil
+	case RoundCeiling:
+		if r.Sign() > 0 {
+			return away(), nil
+		}
+		return quo, nil
+	case RoundFloor:
+		if r.Sign() < 0 {
+			return away(), nil
+		}
+		return quo, nil
+	}
+
+	return nil, fmt.Errorf("unknown rounding mode %s", mode)
+}
@@ -0,0 +1,147 @@
+package aicost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Money_Round_Modes(t *testing.T) {
+	// amounts in tenths, rounded to whole units
+	amounts := []struct {
+		units int64
+		nanos int32
+	}{
+		{2, 500000000},
+		{3, 500000000},
+		{2, 400000000},
+		{2, 600000000},
+		{-2, -500000000},
+		{-3, -500000000},
+		{-2, -400000000},
+		{-2, -600000000},
+		{1, 0},
+	}
+
+	tests := []struct {
+		mode RoundingMode
+		want []int64
+	}{
+		{mode: RoundHalfEven, want: []int64{2, 4, 2, 3, -2, -4, -2, -3, 1}},
+		{mode: RoundHalfUp, want: []int64{3, 4, 2, 3, -3, -4, -2, -3, 1}},
+		{mode: RoundUp, want: []int64{3, 4, 3, 3, -3, -4, -3, -3, 1}},
+		{mode: RoundDown, want: []int64{2, 3, 2, 2, -2, -3, -2, -2, 1}},
+		{mode: RoundCeiling, want: []int64{3, 4, 3, 3, -2, -3, -2, -2, 1}},
+		{mode: RoundFloor, want: []int64{2, 3, 2, 2, -3, -4, -3, -3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			for i, a := range amounts {
+				m := &Money{Units: a.units, Nanos: a.nanos, CurrencyCode: "USD"}
+				got, err := m.Round(0, tt.mode)
+				assert.NoError(t, err)
+				assert.Equal(t, &Money{Units: tt.want[i], Nanos: 0, CurrencyCode: "USD"}, got, "%d.%09d", a.units, a.nanos)
+			}
+		})
+	}
+}
+
+func Test_Money_Round(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   *Money
+		places  int
+		mode    RoundingMode
+		want    *Money
+		wantErr bool
+	}{
+		{
+			name:   "cents half even",
+			money:  &Money{Units: 0, Nanos: 125000000, CurrencyCode: "USD"},
+			places: 2,
+			mode:   RoundHalfEven,
+			want:   &Money{Units: 0, Nanos: 120000000, CurrencyCode: "USD"},
+		},
+		{
+			name:   "cents ceiling of a sub-cent charge",
+			money:  &Money{Units: 0, Nanos: 3000, CurrencyCode: "USD"},
+			places: 2,
+			mode:   RoundCeiling,
+			want:   &Money{Units: 0, Nanos: 10000000, CurrencyCode: "USD"},
+		},
+		{
+			name:   "nine places is a no-op",
+			money:  &Money{Units: 1, Nanos: 123456789, CurrencyCode: "USD"},
+			places: 9,
+			mode:   RoundUp,
+			want:   &Money{Units: 1, Nanos: 123456789, CurrencyCode: "USD"},
+		},
+		{
+			name:   "carry into units",
+			money:  &Money{Units: 1, Nanos: 999500000, CurrencyCode: "USD"},
+			places: 3,
+			mode:   RoundHalfUp,
+			want:   &Money{Units: 2, Nanos: 0, CurrencyCode: "USD"},
+		},
+		{
+			name:    "too many places",
+			money:   &Money{Units: 1, Nanos: 0, CurrencyCode: "USD"},
+			places:  10,
+			mode:    RoundHalfEven,
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			money:   &Money{Units: 1, Nanos: 1, CurrencyCode: "USD"},
+			places:  2,
+			mode:    RoundingMode(42),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Round(tt.places, tt.mode)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Money_RoundToMinorUnits(t *testing.T) {
+	tests := []struct {
+		name  string
+		money *Money
+		want  *Money
+	}{
+		{
+			name:  "USD cents",
+			money: &Money{Units: 1, Nanos: 235000000, CurrencyCode: "USD"},
+			want:  &Money{Units: 1, Nanos: 240000000, CurrencyCode: "USD"},
+		},
+		{
+			name:  "JPY has no minor units",
+			money: &Money{Units: 12, Nanos: 500000000, CurrencyCode: "JPY"},
+			want:  &Money{Units: 12, Nanos: 0, CurrencyCode: "JPY"},
+		},
+		{
+			name:  "KWD has three places",
+			money: &Money{Units: 1, Nanos: 234500000, CurrencyCode: "KWD"},
+			want:  &Money{Units: 1, Nanos: 234000000, CurrencyCode: "KWD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.RoundToMinorUnits(RoundHalfEven)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
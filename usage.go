@@ -0,0 +1,120 @@
+package aicost
+
+import "fmt"
+
+// UsageKind is a category of billed tokens
+type UsageKind string
+
+const (
+	UsageInput       UsageKind = "input"
+	UsageCachedInput UsageKind = "cached_input"
+	UsageCacheWrite  UsageKind = "cache_write"
+	UsageOutput      UsageKind = "output"
+	UsageReasoning   UsageKind = "reasoning"
+	UsageImage       UsageKind = "image"
+	UsageAudioInput  UsageKind = "audio_input"
+	UsageAudioOutput UsageKind = "audio_output"
+)
+
+// Usage is the token usage of a single request, every kind of token is counted once
+type Usage struct {
+	// InputTokens are the fresh text input tokens, not read from or written to the cache
+	InputTokens int64 `json:"input_tokens" yaml:"input_tokens"`
+	// OutputTokens are the generated text tokens, without the reasoning tokens
+	OutputTokens int64 `json:"output_tokens" yaml:"output_tokens"`
+	// CachedInputTokens are the input tokens read from the cache
+	CachedInputTokens int64 `json:"cached_input_tokens" yaml:"cached_input_tokens"`
+	// CacheWriteTokens are the input tokens written to the cache
+	CacheWriteTokens int64 `json:"cache_write_tokens" yaml:"cache_write_tokens"`
+	// ReasoningTokens are the generated tokens spent on reasoning
+	ReasoningTokens int64 `json:"reasoning_tokens" yaml:"reasoning_tokens"`
+	// ImageTokens are the input tokens of images
+	ImageTokens int64 `json:"image_tokens" yaml:"image_tokens"`
+	// AudioInputTokens are the input tokens of audio
+	AudioInputTokens int64 `json:"audio_input_tokens" yaml:"audio_input_tokens"`
+	// AudioOutputTokens are the generated audio tokens
+	AudioOutputTokens int64 `json:"audio_output_tokens" yaml:"audio_output_tokens"`
+}
+
+// PromptTokens returns the size of the prompt, all the input kinds together
+func (u Usage) PromptTokens() int64 {
+	return u.InputTokens + u.CachedInputTokens + u.CacheWriteTokens + u.ImageTokens + u.AudioInputTokens
+}
+
+// Validate checks that no token count of the usage is negative
+func (u Usage) Validate() error {
+	for _, kind := range usageKinds {
+		if tokens := u.Tokens(kind); tokens < 0 {
+			return fmt.Errorf("%s tokens must not be negative: %d", kind, tokens)
+		}
+	}
+
+	return nil
+}
+
+// Tokens returns the token count of a usage kind
+func (u Usage) Tokens(kind UsageKind) int64 {
+	switch kind {
+	case UsageInput:
+		return u.InputTokens
+	case UsageCachedInput:
+		return u.CachedInputTokens
+	case UsageCacheWrite:
+		return u.CacheWriteTokens
+	case UsageOutput:
+		return u.OutputTokens
+	case UsageReasoning:
+		return u.ReasoningTokens
+	case UsageImage:
+		return u.ImageTokens
+	case UsageAudioInput:
+		return u.AudioInputTokens
+	case UsageAudioOutput:
+		return u.AudioOutputTokens
+	}
+
+	return 0
+}
+
+// usageKinds is the order of the lines in a CostBreakdown
+var usageKinds = []UsageKind{
+	UsageInput,
+	UsageCachedInput,
+	UsageCacheWrite,
+	UsageImage,
+	UsageAudioInput,
+	UsageOutput,
+	UsageReasoning,
+	UsageAudioOutput,
+}
+
+// BreakdownLine is the cost of one kind of tokens
+type BreakdownLine struct {
+	Kind     UsageKind `json:"kind" yaml:"kind"`
+	CostLine `yaml:",inline"`
+}
+
+// CostBreakdown is the itemized cost of a Usage
+type CostBreakdown struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Model    string `json:"model" yaml:"model"`
+	// Tier is the name of the applied price tier, empty for the base prices
+	Tier string `json:"tier,omitempty" yaml:"tier,omitempty"`
+	// Resolution records how the requested name was resolved, nil when it matched Model exactly
+	Resolution *Resolution `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	// Lines holds a line for every kind of tokens used
+	Lines []BreakdownLine `json:"lines" yaml:"lines"`
+	// Total is the sum of all the lines
+	Total CostLine `json:"total" yaml:"total"`
+}
+
+// Line returns the line of a usage kind, nil if the kind was not used
+func (b *CostBreakdown) Line(kind UsageKind) *BreakdownLine {
+	for i := range b.Lines {
+		if b.Lines[i].Kind == kind {
+			return &b.Lines[i]
+		}
+	}
+
+	return nil
+}
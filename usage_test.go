@@ -0,0 +1,112 @@
+package aicost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Usage_PromptTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage Usage
+		want  int64
+	}{
+		{
+			name:  "empty usage",
+			usage: Usage{},
+			want:  0,
+		},
+		{
+			name: "all input kinds",
+			usage: Usage{
+				InputTokens:       100,
+				OutputTokens:      1000,
+				CachedInputTokens: 200,
+				CacheWriteTokens:  300,
+				ReasoningTokens:   2000,
+				ImageTokens:       400,
+				AudioInputTokens:  500,
+				AudioOutputTokens: 3000,
+			},
+			want: 1500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.usage.PromptTokens())
+		})
+	}
+}
+
+func Test_Usage_Tokens(t *testing.T) {
+	usage := Usage{
+		InputTokens:       1,
+		OutputTokens:      2,
+		CachedInputTokens: 3,
+		CacheWriteTokens:  4,
+		ReasoningTokens:   5,
+		ImageTokens:       6,
+		AudioInputTokens:  7,
+		AudioOutputTokens: 8,
+	}
+
+	tests := []struct {
+		kind UsageKind
+		want int64
+	}{
+		{kind: UsageInput, want: 1},
+		{kind: UsageOutput, want: 2},
+		{kind: UsageCachedInput, want: 3},
+		{kind: UsageCacheWrite, want: 4},
+		{kind: UsageReasoning, want: 5},
+		{kind: UsageImage, want: 6},
+		{kind: UsageAudioInput, want: 7},
+		{kind: UsageAudioOutput, want: 8},
+		{kind: UsageKind("video"), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			assert.Equal(t, tt.want, usage.Tokens(tt.kind))
+		})
+	}
+}
+
+func Test_Usage_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		usage   Usage
+		wantErr string
+	}{
+		{name: "empty", usage: Usage{}},
+		{name: "all kinds", usage: Usage{InputTokens: 1, OutputTokens: 1, CachedInputTokens: 1, CacheWriteTokens: 1, ReasoningTokens: 1, ImageTokens: 1, AudioInputTokens: 1, AudioOutputTokens: 1}},
+		{name: "negative input", usage: Usage{InputTokens: -1}, wantErr: "input tokens must not be negative: -1"},
+		{name: "negative cache write", usage: Usage{InputTokens: 10, CacheWriteTokens: -10}, wantErr: "cache_write tokens must not be negative: -10"},
+		{name: "negative audio output", usage: Usage{AudioOutputTokens: -3}, wantErr: "audio_output tokens must not be negative: -3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.usage.Validate()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_CostBreakdown_Line(t *testing.T) {
+	breakdown := &CostBreakdown{
+		Lines: []BreakdownLine{
+			{Kind: UsageInput, CostLine: CostLine{Tokens: 10}},
+			{Kind: UsageOutput, CostLine: CostLine{Tokens: 20}},
+		},
+	}
+
+	assert.Equal(t, int64(20), breakdown.Line(UsageOutput).Tokens)
+	assert.Nil(t, breakdown.Line(UsageReasoning))
+}
@@ -0,0 +1,134 @@
+package aicost
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CatalogWatcher polls a pricing catalog file and swaps the models of a Counter when its content changes
+// an invalid or empty catalog is reported and the Counter keeps the models it has
+type CatalogWatcher struct {
+	path     string
+	counter  *Counter
+	interval time.Duration
+
+	mu       sync.Mutex
+	hash     [sha256.Size]byte
+	loaded   bool
+	onReload func(*Catalog)
+	onError  func(error)
+}
+
+// NewCatalogWatcher returns a watcher of the catalog at path, the format is picked by the file extension
+func NewCatalogWatcher(path string, counter *Counter, interval time.Duration) *CatalogWatcher {
+	return &CatalogWatcher{
+		path:     path,
+		counter:  counter,
+		interval: interval,
+	}
+}
+
+// OnReload sets the function called with every catalog swapped into the Counter
+func (w *CatalogWatcher) OnReload(fn func(*Catalog)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onReload = fn
+}
+
+// OnError sets the function called with every failed reload
+func (w *CatalogWatcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onError = fn
+}
+
+// Reload reads the catalog and swaps it into the Counter if its content changed since the last swap
+// it reports whether the models were swapped, the callbacks are called after the swap without holding the watcher
+func (w *CatalogWatcher) Reload() (bool, error) {
+	w.mu.Lock()
+	catalog, err := w.reload()
+	onReload, onError := w.onReload, w.onError
+	w.mu.Unlock()
+
+	if err != nil {
+		if onError != nil {
+			onError(err)
+		}
+		return false, err
+	}
+	if catalog == nil {
+		return false, nil
+	}
+
+	if onReload != nil {
+		onReload(catalog)
+	}
+
+	return true, nil
+}
+
+// reload swaps the catalog into the Counter and returns it, nil when its content did not change
+// the caller holds the lock
+func (w *CatalogWatcher) reload() (*Catalog, error) {
+	format, err := CatalogFormatForPath(w.path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog %s: %w", w.path, err)
+	}
+
+	hash := sha256.Sum256(data)
+	if w.loaded && hash == w.hash {
+		return nil, nil
+	}
+
+	catalog, err := ParseCatalog(data, format)
+	if err != nil {
+		var catalogErr *CatalogError
+		if errors.As(err, &catalogErr) {
+			catalogErr.File = w.path
+		}
+		return nil, err
+	}
+	if len(catalog.Models) == 0 {
+		return nil, fmt.Errorf("catalog %s has no models: %w", w.path, ErrInvalidCatalog)
+	}
+
+	w.counter.Models(catalog.Models)
+	w.hash = hash
+	w.loaded = true
+
+	return catalog, nil
+}
+
+// Run reloads the catalog right away and then on every interval until ctx is done
+// reload errors are reported to OnError and do not stop the watcher, an interval that is not positive is an error
+func (w *CatalogWatcher) Run(ctx context.Context) error {
+	if w.interval <= 0 {
+		return fmt.Errorf("catalog watcher interval must be greater than 0: %s", w.interval)
+	}
+
+	_, _ = w.Reload()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_, _ = w.Reload()
+		}
+	}
+}
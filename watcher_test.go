@@ -0,0 +1,184 @@
+package aicost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWatchedCatalog = `models:
+  - provider: openai
+    model: gpt-4
+    cost_input: {units: 0, nanos: %d, currency_code: USD}
+    cost_output: {units: 0, nanos: 60000, currency_code: USD}
+`
+
+func writeWatchedCatalog(t *testing.T, path string, content string) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func Test_CatalogWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	accountant := NewAccountant([]Model{}, NewConverter("USD", testRates), false)
+	watcher := NewCatalogWatcher(path, accountant, time.Minute)
+
+	var reported []error
+	var reloaded []*Catalog
+	watcher.OnError(func(err error) { reported = append(reported, err) })
+	watcher.OnReload(func(c *Catalog) { reloaded = append(reloaded, c) })
+
+	// missing file
+	swapped, err := watcher.Reload()
+	assert.False(t, swapped)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// first valid catalog
+	writeWatchedCatalog(t, path, fmtCatalog(30000))
+	swapped, err = watcher.Reload()
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+	assertInputNanos(t, accountant, 30000000)
+
+	// unchanged content is not swapped again
+	swapped, err = watcher.Reload()
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+
+	// an invalid catalog keeps the previous models
+	writeWatchedCatalog(t, path, "models:\n  - provider: openai\n    model: gpt-4\n    cost_input: {units: 1, nanos: -1, currency_code: USD}\n")
+	swapped, err = watcher.Reload()
+	assert.False(t, swapped)
+	assert.ErrorIs(t, err, ErrInvalidCatalog)
+	assert.ErrorContains(t, err, path+":2:5:")
+	assertInputNanos(t, accountant, 30000000)
+
+	// an empty catalog keeps the previous models
+	writeWatchedCatalog(t, path, "models: []\n")
+	swapped, err = watcher.Reload()
+	assert.False(t, swapped)
+	assert.ErrorIs(t, err, ErrInvalidCatalog)
+	assertInputNanos(t, accountant, 30000000)
+
+	// a new valid catalog is swapped in
+	writeWatchedCatalog(t, path, fmtCatalog(10000))
+	swapped, err = watcher.Reload()
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+	assertInputNanos(t, accountant, 10000000)
+
+	assert.Len(t, reported, 3)
+	assert.Len(t, reloaded, 2)
+}
+
+func Test_CatalogWatcher_Reload_Callbacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	accountant := NewAccountant([]Model{}, NewConverter("USD", testRates), false)
+	watcher := NewCatalogWatcher(path, accountant, time.Minute)
+
+	// the callbacks can use the watcher
+	var reloaded, reported int
+	watcher.OnReload(func(*Catalog) {
+		reloaded++
+		swapped, err := watcher.Reload()
+		assert.NoError(t, err)
+		assert.False(t, swapped)
+	})
+	watcher.OnError(func(error) {
+		reported++
+		watcher.OnError(func(error) { reported += 10 })
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := watcher.Reload()
+		assert.Error(t, err)
+		_, err = watcher.Reload()
+		assert.Error(t, err)
+
+		writeWatchedCatalog(t, path, fmtCatalog(30000))
+		swapped, err := watcher.Reload()
+		assert.NoError(t, err)
+		assert.True(t, swapped)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a callback calling the watcher deadlocked")
+	}
+	assert.Equal(t, 1, reloaded)
+	assert.Equal(t, 11, reported)
+}
+
+func Test_CatalogWatcher_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	writeWatchedCatalog(t, path, fmtCatalog(30000))
+
+	accountant := NewAccountant([]Model{}, NewConverter("USD", testRates), false)
+	watcher := NewCatalogWatcher(path, accountant, 5*time.Millisecond)
+
+	var mu sync.Mutex
+	reloads := 0
+	watcher.OnReload(func(*Catalog) {
+		mu.Lock()
+		defer mu.Unlock()
+		reloads++
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return reloads == 1
+	}, time.Second, time.Millisecond)
+
+	writeWatchedCatalog(t, path, fmtCatalog(10000))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return reloads == 2
+	}, time.Second, time.Millisecond)
+	assertInputNanos(t, accountant, 10000000)
+
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+}
+
+func Test_CatalogWatcher_Run_Interval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.yaml")
+	writeWatchedCatalog(t, path, fmtCatalog(30000))
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		accountant := NewAccountant([]Model{}, NewConverter("USD", testRates), false)
+		watcher := NewCatalogWatcher(path, accountant, interval)
+
+		assert.Error(t, watcher.Run(context.Background()))
+		assert.Empty(t, accountant.Models(nil))
+	}
+}
+
+func fmtCatalog(inputNanos int) string {
+	return fmt.Sprintf(testWatchedCatalog, inputNanos)
+}
+
+func assertInputNanos(t *testing.T, accountant *Counter, want int32) {
+	t.Helper()
+
+	cost, _, err := accountant.CostForModelInput("openai", "gpt-4", "USD", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, want, cost.Nanos)
+}